@@ -8,6 +8,7 @@ require (
 	github.com/joho/godotenv v1.5.1
 	github.com/manifoldco/promptui v0.9.0
 	github.com/tmc/langchaingo v0.1.13
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
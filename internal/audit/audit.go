@@ -0,0 +1,192 @@
+// Package audit keeps a local record of every prompt gommit sends to a provider
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// DefaultRetentionDays is how long stored prompts are kept when audit.retention_days is not set
+const DefaultRetentionDays = 30
+
+// Kinds of prompt
+const (
+	KindMessage = "message" // the prompt that writes the commit message
+	KindRepair  = "repair"  // a retry with the lint violations of the previous message
+	KindSummary = "summary" // a group of files summarized for a large changeset
+)
+
+// Record is one prompt sent to a provider
+type Record struct {
+	Time     time.Time `json:"time"`
+	Repo     string    `json:"repo"`
+	Provider string    `json:"provider"`
+	Endpoint string    `json:"endpoint"`
+	Model    string    `json:"model"`
+	Kind     string    `json:"kind"`
+	Hash     string    `json:"hash"` // sha256 of the exact prompt, hex encoded
+	Bytes    int       `json:"bytes"`
+	Stored   bool      `json:"stored,omitempty"` // the full prompt was kept in PromptDir
+}
+
+// Dir returns the audit directory: $XDG_STATE_HOME/gommit/audit, or ~/.local/state/gommit/audit
+func Dir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "gommit", "audit")
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "gommit-audit" // fallback to current directory
+	}
+	return filepath.Join(homeDir, ".local", "state", "gommit", "audit")
+}
+
+// LogPath returns the location of the audit log
+func LogPath() string {
+	return filepath.Join(Dir(), "audit.jsonl")
+}
+
+// PromptDir returns where the full prompts are stored, one file per hash
+func PromptDir() string {
+	return filepath.Join(Dir(), "prompts")
+}
+
+// Hash returns the hash recorded for a prompt
+func Hash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// Log records a prompt about to be sent. With cfg.StorePrompts the prompt itself is kept as well,
+// and stored prompts older than the retention period are deleted.
+func Log(cfg types.AuditConfig, r Record, prompt string) error {
+	r.Hash, r.Bytes = Hash(prompt), len(prompt)
+	if err := os.MkdirAll(Dir(), 0o700); err != nil {
+		return fmt.Errorf("could not create audit directory: %w", err)
+	}
+
+	if cfg.StorePrompts {
+		if err := storePrompt(r.Hash, prompt); err != nil {
+			return err
+		}
+		r.Stored = true
+		retention := cfg.RetentionDays
+		if retention <= 0 {
+			retention = DefaultRetentionDays
+		}
+		if err := Prune(time.Now().AddDate(0, 0, -retention)); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(LogPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("could not write audit log: %w", err)
+	}
+	return nil
+}
+
+// storePrompt keeps a prompt under its hash; an identical prompt refreshes the file's age
+func storePrompt(hash, prompt string) error {
+	if err := os.MkdirAll(PromptDir(), 0o700); err != nil {
+		return fmt.Errorf("could not create prompt directory: %w", err)
+	}
+	path := filepath.Join(PromptDir(), hash+".txt")
+	if err := os.WriteFile(path, []byte(prompt), 0o600); err != nil {
+		return fmt.Errorf("could not store prompt: %w", err)
+	}
+	return nil
+}
+
+// Prune deletes the stored prompts last written before cutoff; the log itself is kept
+func Prune(cutoff time.Time) error {
+	entries, err := os.ReadDir(PromptDir())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read prompt directory: %w", err)
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || e.IsDir() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(PromptDir(), e.Name())); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not delete expired prompt: %w", err)
+		}
+	}
+	return nil
+}
+
+// Read returns the records logged at or after since; malformed lines are skipped
+func Read(since time.Time) ([]Record, error) {
+	f, err := os.Open(LogPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		if json.Unmarshal(scanner.Bytes(), &r) != nil || r.Time.Before(since) {
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// Prompt returns the stored prompt whose hash starts with prefix, and its full hash
+func Prompt(prefix string) (string, string, error) {
+	if len(prefix) < 8 {
+		return "", "", fmt.Errorf("hash prefix %q is too short (8 characters at least)", prefix)
+	}
+	entries, err := os.ReadDir(PromptDir())
+	if err != nil && !os.IsNotExist(err) {
+		return "", "", fmt.Errorf("could not read prompt directory: %w", err)
+	}
+
+	var matches []string
+	for _, e := range entries {
+		if hash := strings.TrimSuffix(e.Name(), ".txt"); strings.HasPrefix(hash, strings.ToLower(prefix)) {
+			matches = append(matches, hash)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", "", fmt.Errorf("no stored prompt with hash %s (not stored, or expired)", prefix)
+	case 1:
+	default:
+		return "", "", fmt.Errorf("hash prefix %s is ambiguous", prefix)
+	}
+
+	data, err := os.ReadFile(filepath.Join(PromptDir(), matches[0]+".txt"))
+	if err != nil {
+		return "", "", fmt.Errorf("could not read stored prompt: %w", err)
+	}
+	return string(data), matches[0], nil
+}
@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// TestLog records prompts with and without storing them and checks the retention of stored prompts.
+func TestLog(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	if err := Log(types.AuditConfig{Enabled: true}, Record{Time: time.Now(), Provider: "openai", Kind: KindMessage}, "hashed only"); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	cfg := types.AuditConfig{Enabled: true, StorePrompts: true, RetentionDays: 7}
+	if err := Log(cfg, Record{Time: time.Now(), Provider: "ollama", Kind: KindMessage}, "old prompt"); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+
+	old := time.Now().AddDate(0, 0, -8)
+	if err := os.Chtimes(filepath.Join(PromptDir(), Hash("old prompt")+".txt"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := Log(cfg, Record{Time: time.Now(), Provider: "ollama", Kind: KindRepair}, "new prompt"); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+
+	records, err := Read(time.Now().Add(-time.Hour))
+	if err != nil || len(records) != 3 {
+		t.Fatalf("Read() = %+v, %v", records, err)
+	}
+	if r := records[0]; r.Hash != Hash("hashed only") || r.Bytes != len("hashed only") || r.Stored {
+		t.Errorf("first record = %+v", r)
+	}
+	if _, _, err := Prompt(Hash("hashed only")); err == nil {
+		t.Error("a prompt was stored without store_prompts")
+	}
+	if _, _, err := Prompt(Hash("old prompt")[:10]); err == nil {
+		t.Error("the expired prompt was not deleted")
+	}
+	if prompt, hash, err := Prompt(records[2].Hash[:10]); err != nil || prompt != "new prompt" || hash != records[2].Hash {
+		t.Errorf("Prompt() = %q, %q, %v", prompt, hash, err)
+	}
+}
@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edhuardotierrez/gommit/internal/git"
+	"github.com/edhuardotierrez/gommit/internal/lint"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// DefaultMaxAge is how long generated messages are reused
+const DefaultMaxAge = 7 * 24 * time.Hour
+
+// lastFile holds the last message shown to the user, kept until it is committed
+const lastFile = "last.json"
+
+// Entry is a generated message stored for reuse
+type Entry struct {
+	Key       string           `json:"key"`
+	Tree      string           `json:"tree"`
+	Provider  string           `json:"provider"`
+	Model     string           `json:"model"`
+	Style     string           `json:"style"`
+	Message   string           `json:"message"`
+	Warnings  []lint.Violation `json:"warnings,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// Dir returns the cache directory of the current repository: .git/gommit, or a per-repository
+// directory under the user cache dir (XDG_CACHE_HOME) when the git directory cannot be resolved
+func Dir() (string, error) {
+	if dir, err := git.GetGitPath("gommit"); err == nil {
+		return dir, nil
+	}
+
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not resolve cache directory: %w", err)
+	}
+	root := git.GetRootPath()
+	if root == "" {
+		return "", fmt.Errorf("not a git repository")
+	}
+	return filepath.Join(base, "gommit", hash(root)[:16]), nil
+}
+
+// Key identifies a generation: the staged tree plus everything that shapes the output
+func Key(tree, provider, model, style string, temperature float64, prompt string) string {
+	return hash(strings.Join([]string{tree, provider, model, style, strconv.FormatFloat(temperature, 'f', -1, 64), hash(prompt)}, "\x00"))
+}
+
+// MaxAge returns the configured expiry
+func MaxAge(cfg types.CacheConfig) time.Duration {
+	if cfg.MaxAgeHours > 0 {
+		return time.Duration(cfg.MaxAgeHours) * time.Hour
+	}
+	return DefaultMaxAge
+}
+
+// Get returns the entry for key when it exists and is younger than maxAge
+func Get(key string, maxAge time.Duration) (*Entry, bool) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, false
+	}
+	entry, err := read(filepath.Join(dir, "messages", key+".json"))
+	if err != nil || time.Since(entry.CreatedAt) > maxAge {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Put stores an entry and prunes the expired ones
+func Put(entry Entry, maxAge time.Duration) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	messages := filepath.Join(dir, "messages")
+	if err := os.MkdirAll(messages, 0o700); err != nil {
+		return fmt.Errorf("could not create cache directory: %w", err)
+	}
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	if err := write(filepath.Join(messages, entry.Key+".json"), entry); err != nil {
+		return err
+	}
+
+	prune(messages, maxAge)
+	return nil
+}
+
+// SaveLast remembers the message about to be committed, so a failed commit can be retried without generating
+func SaveLast(entry Entry) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("could not create cache directory: %w", err)
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	return write(filepath.Join(dir, lastFile), entry)
+}
+
+// Last returns the remembered message, if any
+func Last() (*Entry, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := read(filepath.Join(dir, lastFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return entry, err
+}
+
+// ClearLast forgets the remembered message once it has been committed
+func ClearLast() {
+	if dir, err := Dir(); err == nil {
+		_ = os.Remove(filepath.Join(dir, lastFile))
+	}
+}
+
+func read(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("invalid cache entry %s: %w", path, err)
+	}
+	return &entry, nil
+}
+
+func write(path string, entry Entry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("could not write cache entry: %w", err)
+	}
+	return nil
+}
+
+// prune removes entries older than maxAge
+func prune(dir string, maxAge time.Duration) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		if info, err := f.Info(); err == nil && time.Since(info.ModTime()) > maxAge {
+			_ = os.Remove(filepath.Join(dir, f.Name()))
+		}
+	}
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
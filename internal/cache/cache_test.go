@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCache_StoresMessagesInGitDir covers hits, misses on a different key, expiry and the last message.
+func TestCache_StoresMessagesInGitDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if out, err := exec.Command("git", "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+
+	cacheDir, err := Dir()
+	if err != nil {
+		t.Fatalf("Dir failed: %v", err)
+	}
+	if want := filepath.Join(".git", "gommit"); !strings.HasSuffix(cacheDir, want) {
+		t.Fatalf("Dir() = %q, want it under %s", cacheDir, want)
+	}
+
+	key := Key("tree1", "openai", "gpt-4o-mini", "conventional", 0.7, "prompt")
+	if other := Key("tree1", "openai", "gpt-4o", "conventional", 0.7, "prompt"); other == key {
+		t.Fatalf("keys for different models must differ")
+	}
+	if other := Key("tree1", "openai", "gpt-4o-mini", "conventional", 1, "prompt"); other == key {
+		t.Fatalf("keys for different temperatures must differ")
+	}
+
+	if err := Put(Entry{Key: key, Message: "feat: add cache"}, time.Hour); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if entry, ok := Get(key, time.Hour); !ok || entry.Message != "feat: add cache" {
+		t.Fatalf("Get() = %+v, %v", entry, ok)
+	}
+	if _, ok := Get(Key("tree2", "openai", "gpt-4o-mini", "conventional", 0.7, "prompt"), time.Hour); ok {
+		t.Fatalf("unexpected hit for another tree")
+	}
+
+	old := Entry{Key: "old", Message: "fix: stale", CreatedAt: time.Now().Add(-2 * time.Hour)}
+	if err := Put(old, 3*time.Hour); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, ok := Get("old", time.Hour); ok {
+		t.Fatalf("expired entry was returned")
+	}
+
+	if err := SaveLast(Entry{Tree: "tree1", Message: "feat: retry me"}); err != nil {
+		t.Fatalf("SaveLast failed: %v", err)
+	}
+	if last, err := Last(); err != nil || last == nil || last.Message != "feat: retry me" {
+		t.Fatalf("Last() = %+v, %v", last, err)
+	}
+	ClearLast()
+	if last, err := Last(); err != nil || last != nil {
+		t.Fatalf("Last() after ClearLast = %+v, %v", last, err)
+	}
+}
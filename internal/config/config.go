@@ -1,27 +1,40 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 
+	"gopkg.in/yaml.v3"
+
+	"github.com/edhuardotierrez/gommit/internal/colors"
 	"github.com/edhuardotierrez/gommit/internal/env"
-	"github.com/edhuardotierrez/gommit/internal/setup"
 	"github.com/edhuardotierrez/gommit/internal/types"
 )
 
-// GetConfigPath returns the path to the configuration file
+// GetConfigPath returns the path to the configuration file: ~/gommit.yaml (or .yml) when it
+// exists, so the file can keep comments, and ~/gommit.json otherwise
 func GetConfigPath() string {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "gommit.json" // fallback to current directory
 	}
+	for _, name := range []string{"gommit.yaml", "gommit.yml"} {
+		if _, err := os.Stat(filepath.Join(homeDir, name)); err == nil {
+			return filepath.Join(homeDir, name)
+		}
+	}
 	return filepath.Join(homeDir, "gommit.json")
 }
 
 var sampleConfigMessage = `
 {
+	"version": 3,
 	"default_provider": "openai",
 	"providers": {
 		"openai": {
@@ -31,82 +44,256 @@ var sampleConfigMessage = `
 		}
 	},
 	"truncate_lines": 100,
-	"max_line_width": 300,
+	"max_line_width": 300
 }
 `
 
-// ProviderConfig holds the configuration for a specific LLM provider
-type ProviderConfig struct {
-	APIKey      string  `json:"api_key"`
-	Model       string  `json:"model"`
-	Temperature float64 `json:"temperature,omitempty"`
+// defaultProvider is used when the config file does not set default_provider
+const defaultProvider = "openai"
+
+// ErrNotFound is returned when the configuration file does not exist
+var ErrNotFound = errors.New("config file not found")
+
+// InvalidError lists the problems that keep a configuration file from being used
+type InvalidError struct {
+	Path     string
+	Problems []Problem
 }
 
-// Config holds the application configuration
-type Config struct {
-	DefaultProvider string                    `json:"default_provider"`
-	Providers       map[string]ProviderConfig `json:"providers"`
+func (e *InvalidError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid config file %s:", e.Path)
+	for _, p := range e.Problems {
+		fmt.Fprintf(&b, "\n  - %s", p)
+	}
+	b.WriteString("\nRun `gommit config edit` to fix it, or `gommit doctor` to check the whole setup.")
+	return b.String()
 }
 
-// Load reads the configuration file from the user's home directory
+// Load reads, migrates and validates the configuration and fills in the defaults. The user file is
+// merged over the system and shared config (see LoadEffective). It is what commands that call a
+// provider use; any problem is an *InvalidError, and ErrNotFound means no configuration exists.
 func Load() (*types.Config, error) {
-
 	env.LoadFile()
 
-	homeDir, err := os.UserHomeDir()
+	eff, err := LoadEffective(true)
+	var invalid *InvalidError
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.As(err, &invalid) {
+		return nil, fmt.Errorf("%w\n%s", err, sampleConfigMessage)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("could not get user home directory: %w", err)
+		return nil, err
+	}
+	for _, w := range eff.Warnings {
+		colors.WarningOutput("⚠️ %s\n", w)
 	}
 
-	configPath := filepath.Join(homeDir, "gommit.json")
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			_, _ = setup.CreateConfigWizard(configPath)
-			fmt.Printf("\n🚀 You're all set! Run 'gommit' to start using gommit.\n")
-			os.Exit(0)
-		}
-		return nil, fmt.Errorf("could not read config file at %s: %w\n%s", configPath, err, sampleConfigMessage)
+	cfg := eff.Config
+	applyDefaults(cfg)
+	return cfg, nil
+}
+
+// Read loads the configuration without requiring a usable provider, for commands that only need
+// the commit rules (e.g. `gommit lint`). Problems are ignored and no configuration yields the
+// defaults.
+func Read() (*types.Config, error) {
+	cfg := &types.Config{}
+	eff, err := LoadEffective(false)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
 	}
+	if err == nil {
+		cfg = eff.Config
+	}
+
+	applyDefaults(cfg)
+	if pc, ok := cfg.Providers[cfg.DefaultProvider]; ok && pc.CommitStyle != "" {
+		cfg.CommitStyle = pc.CommitStyle
+	}
+	return cfg, nil
+}
 
-	var config types.Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("could not parse config file: %w", err)
+// LoadFile reads and migrates a configuration file as it is written, without validating it or
+// filling in defaults, for the wizards that edit and save it back
+func LoadFile(path string) (*types.Config, error) {
+	raw, err := ReadSettings(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := decode(raw)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %w", path, err)
 	}
+	return cfg, nil
+}
 
-	if config.DefaultProvider == "" {
-		config.DefaultProvider = "openai"
+// ReadSettings reads and migrates a configuration file as an object holding only the keys the file
+// sets, for `gommit config get|set|unset`
+func ReadSettings(path string) (map[string]any, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return nil, err
 	}
 
-	providerConfig, ok := config.Providers[config.DefaultProvider]
+	root, err := parseDocument(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	raw, err := nodeValue(root)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	obj, ok := raw.(map[string]any)
 	if !ok {
-		return nil, fmt.Errorf("default provider %s not found in config", config.DefaultProvider)
+		return nil, fmt.Errorf("could not parse config file %s: not an object", path)
 	}
+	if _, err := migrate(obj); err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	return obj, nil
+}
 
-	if providerConfig.APIKey == "" {
-		return nil, fmt.Errorf("api_key is required for provider %s", config.DefaultProvider)
+// Decode returns the configuration described by the settings of a file, without changing them
+func Decode(raw map[string]any) (*types.Config, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
 	}
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	return decode(obj)
+}
 
-	if providerConfig.Temperature == 0 {
-		providerConfig.Temperature = 0.7 // Set default temperature if not specified
+// Save writes the configuration file in the current format. The values of cfg are merged into the
+// existing file, which keeps its key order, its comments (YAML) and the fields gommit does not
+// know. The file is replaced atomically, after its previous content is kept in a rotating backup.
+func Save(path string, cfg *types.Config) error {
+	path = resolveLinks(path)
+	unlock, err := lockFile(path)
+	if err != nil {
+		return fmt.Errorf("could not lock config: %w", err)
 	}
+	defer unlock()
+
+	return save(path, cfg)
+}
 
-	if providerConfig.Temperature < 0 || providerConfig.Temperature > 1 {
-		return nil, fmt.Errorf("temperature must be between 0 and 1 for provider %s", config.DefaultProvider)
+// Edit applies change to the settings of the configuration file (see ReadSettings) and saves it,
+// holding the file lock from the read to the write so concurrent gommit runs do not lose each
+// other's changes. Only the keys change sets or deletes are written, and a missing file starts empty.
+func Edit(path string, change func(raw map[string]any) error) error {
+	path = resolveLinks(path)
+	unlock, err := lockFile(path)
+	if err != nil {
+		return fmt.Errorf("could not lock config: %w", err)
 	}
+	defer unlock()
 
-	if config.TruncateLines == 0 {
-		config.TruncateLines = types.DefaultTruncateLines
+	raw, err := ReadSettings(path)
+	if errors.Is(err, ErrNotFound) {
+		raw, err = map[string]any{}, nil
 	}
+	if err != nil {
+		return err
+	}
+	if err := change(raw); err != nil {
+		return err
+	}
+	raw["version"] = float64(CurrentVersion)
+	values, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("could not marshal config: %w", err)
+	}
+	return saveValues(path, values)
+}
+
+// save merges cfg into the file at path; the caller holds the lock
+func save(path string, cfg *types.Config) error {
+	cfg.Version = CurrentVersion
+	values, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("could not marshal config: %w", err)
+	}
+	return saveValues(path, values)
+}
 
-	if config.MaxLineWidth == 0 {
-		config.MaxLineWidth = types.DefaultMaxLineWidth
+// saveValues merges the JSON values of a whole configuration into the file at path: keys gommit
+// knows and values leaves out are removed, the others are kept. The caller holds the lock.
+func saveValues(path string, values []byte) error {
+	src, err := jsonNode(values)
+	if err != nil {
+		return fmt.Errorf("could not marshal config: %w", err)
 	}
 
-	if config.CommitStyle == "" {
-		config.CommitStyle = types.DefaultCommitStyle
+	previous, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not read config file %s: %w", path, err)
 	}
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if len(bytes.TrimSpace(previous)) > 0 {
+		// a file that no longer parses is replaced (it is still kept in the backup)
+		if doc, err := parseDocument(path, previous); err == nil && doc.Kind == yaml.MappingNode {
+			root = doc
+		}
+	}
+	mergeNode(root, src, reflect.TypeOf(types.Config{}))
 
-	config.Providers[config.DefaultProvider] = providerConfig
-	return &config, nil
+	data, err := encodeDocument(path, root, previous)
+	if err != nil {
+		return fmt.Errorf("could not marshal config: %w", err)
+	}
+	if bytes.Equal(data, previous) {
+		return nil
+	}
+	if err := writeFile(path, data, previous); err != nil {
+		return fmt.Errorf("could not write config: %w", err)
+	}
+	return nil
+}
+
+func readFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// decode migrates a config file object to the current version and decodes it
+func decode(raw map[string]any) (*types.Config, error) {
+	if _, err := migrate(raw); err != nil {
+		return nil, err
+	}
+	defaultTemperatures(raw, types.DefaultTemperature)
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var cfg types.Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// applyDefaults fills in the settings a config file may leave out
+func applyDefaults(cfg *types.Config) {
+	if cfg.DefaultProvider == "" {
+		cfg.DefaultProvider = defaultProvider
+	}
+	if cfg.TruncateLines == 0 {
+		cfg.TruncateLines = types.DefaultTruncateLines
+	}
+	if cfg.MaxLineWidth == 0 {
+		cfg.MaxLineWidth = types.DefaultMaxLineWidth
+	}
+	if cfg.CommitStyle == "" {
+		cfg.CommitStyle = types.DefaultCommitStyle
+	}
 }
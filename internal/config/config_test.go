@@ -0,0 +1,761 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// TestCheck_Problems checks the problems reported for a file with typos, missing fields and bad values.
+func TestCheck_Problems(t *testing.T) {
+	data := []byte(`{
+  "default_provider": "openai",
+  "comit_style": "conventional",
+  "providers": {
+    "openai": {"model": "gpt-4o-mini", "temperature": 1.5},
+    "ollama": {"uri": "http://localhost:11434", "model": "llama3"},
+    "mistral": {"api_key": "x"}
+  },
+  "max_line_width": -1,
+  "ticket": {"placement": "header"}
+}`)
+
+	cfg, problems, err := Check(data)
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if cfg == nil {
+		t.Fatal("Check() returned no config")
+	}
+
+	want := []string{
+		`line 3, column 3: comit_style: unknown field (did you mean "commit_style"?)`,
+		`line 9, column 3: max_line_width: must be at least 0`,
+		`line 7, column 5: providers.mistral: unknown name (expected one of: openai, anthropic, ollama, google)`,
+		`line 5, column 40: providers.openai.temperature: must be at most 1`,
+		`line 10, column 14: ticket.placement: unknown value "header" (expected one of: prefix, footer, trailer)`,
+		`line 5, column 5: providers.openai.api_key: is required`,
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.Error())
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("Check() problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// TestCheck_OllamaWithoutAPIKey checks that the required fields come from the provider metadata.
+func TestCheck_OllamaWithoutAPIKey(t *testing.T) {
+	data := []byte(`{"default_provider": "ollama", "providers": {"ollama": {"uri": "http://localhost:11434", "model": "llama3"}}}`)
+	if _, problems, err := Check(data); err != nil || len(problems) > 0 {
+		t.Fatalf("Check() = %v, %v, want no problems", problems, err)
+	}
+
+	data = []byte(`{"default_provider": "ollama", "providers": {"ollama": {"model": "llama3"}}}`)
+	if _, problems, _ := Check(data); len(problems) != 1 || problems[0].Field != "providers.ollama.uri" {
+		t.Fatalf("Check() = %v, want a missing uri", problems)
+	}
+}
+
+// TestCheck_SyntaxErrorPosition checks that parse errors carry the line and column.
+func TestCheck_SyntaxErrorPosition(t *testing.T) {
+	_, _, err := Check([]byte("{\n  \"default_provider\": \"openai\",\n}"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 3, column 1:") {
+		t.Fatalf("Check() error = %v, want the position of the closing brace", err)
+	}
+
+	_, _, err = Check([]byte("{\n  \"max_tokens\": \"many\"\n}"))
+	if err != nil {
+		t.Fatalf("Check() failed on a type mismatch: %v", err)
+	}
+}
+
+// TestSampleConfig checks that the sample shown in error messages is itself a valid config.
+func TestSampleConfig(t *testing.T) {
+	if _, problems, err := Check([]byte(sampleConfigMessage)); err != nil || len(problems) > 0 {
+		t.Fatalf("sample config is invalid: %v %v", problems, err)
+	}
+}
+
+// TestSchema_CoversConfig checks that the schema and types.Config describe the same fields.
+func TestSchema_CoversConfig(t *testing.T) {
+	var walk func(t *testing.T, typ reflect.Type, node *schemaNode, path string)
+	walk = func(t *testing.T, typ reflect.Type, node *schemaNode, path string) {
+		node = node.resolve()
+		switch typ.Kind() {
+		case reflect.Pointer:
+			walk(t, typ.Elem(), node, path)
+		case reflect.Slice:
+			if node.Items == nil {
+				t.Errorf("%s: schema has no items", path)
+				return
+			}
+			walk(t, typ.Elem(), node.Items, path+"[]")
+		case reflect.Map:
+			additional, _ := node.additional()
+			if additional == nil {
+				t.Errorf("%s: schema has no additionalProperties", path)
+				return
+			}
+			walk(t, typ.Elem(), additional, path+".*")
+		case reflect.Struct:
+			fields := map[string]bool{}
+			for i := 0; i < typ.NumField(); i++ {
+				name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+				fields[name] = true
+				prop, ok := node.Properties[name]
+				if !ok {
+					t.Errorf("%s.%s: missing from the schema", path, name)
+					continue
+				}
+				walk(t, typ.Field(i).Type, prop, path+"."+name)
+			}
+			for name := range node.Properties {
+				if !fields[name] && name != "$schema" {
+					t.Errorf("%s.%s: in the schema but not in %s", path, name, typ)
+				}
+			}
+		}
+	}
+	walk(t, reflect.TypeOf(types.Config{}), rootSchema, "")
+
+	if !json.Valid(Schema) {
+		t.Fatal("schema is not valid JSON")
+	}
+}
+
+// TestCheck_Migrates checks that version 1 files are upgraded and that temperature 0 survives.
+func TestCheck_Migrates(t *testing.T) {
+	data := []byte(`{
+  "max_tokens": 500,
+  "providers": {
+    "openai": {"api_key": "x", "model": "gpt-4o-mini", "temperature": 0},
+    "anthropic": {"api_key": "y", "model": "claude-sonnet-4-0"}
+  }
+}`)
+	cfg, problems, err := Check(data)
+	if err != nil || len(problems) > 0 {
+		t.Fatalf("Check() = %v, %v, want no problems", problems, err)
+	}
+	if cfg.Version != CurrentVersion {
+		t.Errorf("Version = %d, want %d", cfg.Version, CurrentVersion)
+	}
+	if cfg.MaxTokens != 0 {
+		t.Errorf("MaxTokens = %d, want the version 1 value dropped", cfg.MaxTokens)
+	}
+	if got := cfg.Providers["openai"].Temperature; got != 0 {
+		t.Errorf("openai temperature = %v, want 0", got)
+	}
+	if got := cfg.Providers["anthropic"].Temperature; got != types.DefaultTemperature {
+		t.Errorf("anthropic temperature = %v, want the default %v", got, types.DefaultTemperature)
+	}
+
+	// From version 2 on, max_tokens is honoured
+	cfg, _, _ = Check([]byte(`{"version": 2, "max_tokens": 300, "providers": {"openai": {"api_key": "x", "model": "m"}}}`))
+	if cfg.MaxTokens != 300 {
+		t.Errorf("MaxTokens = %d, want 300", cfg.MaxTokens)
+	}
+
+	// From version 3 on, tickets are opt-in and ticket.disabled is gone
+	cfg, problems, err = Check([]byte(`{"version": 2, "ticket": {"disabled": true}, "providers": {"openai": {"api_key": "x", "model": "m"}}}`))
+	if err != nil || len(problems) > 0 || cfg.Ticket.Enabled {
+		t.Errorf("Check() = %+v, %v, %v, want ticket.disabled dropped", cfg.Ticket, problems, err)
+	}
+
+	_, _, err = Check([]byte(`{"version": 99}`))
+	if err == nil || !strings.Contains(err.Error(), "newer than this gommit supports") {
+		t.Errorf("Check() error = %v, want a newer version error", err)
+	}
+}
+
+// TestLoad checks the defaults filled in by Load and the error for a missing file.
+func TestLoad(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GOMMIT_SYSTEM_CONFIG", filepath.Join(t.TempDir(), "none.json"))
+
+	if _, err := Load(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Load() error = %v, want ErrNotFound", err)
+	}
+
+	data := `{"providers": {"openai": {"api_key": "x", "model": "gpt-4o-mini", "temperature": 0}}}`
+	if err := os.WriteFile(GetConfigPath(), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.DefaultProvider != "openai" || cfg.CommitStyle != types.DefaultCommitStyle ||
+		cfg.TruncateLines != types.DefaultTruncateLines || cfg.MaxLineWidth != types.DefaultMaxLineWidth {
+		t.Errorf("Load() defaults = %+v", cfg)
+	}
+	if got := cfg.Providers["openai"].Temperature; got != 0 {
+		t.Errorf("temperature = %v, want 0", got)
+	}
+}
+
+// TestSave checks that Save writes the current version and that the file loads back unchanged.
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gommit.json")
+	cfg := &types.Config{
+		DefaultProvider: "ollama",
+		Providers:       map[string]types.ProviderConfig{"ollama": {URI: "http://localhost:11434", Model: "llama3"}},
+		MaxTokens:       200,
+	}
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("mode = %v, want 0600", perm)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+
+	got, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("LoadFile() = %+v, want %+v", got, cfg)
+	}
+}
+
+// TestSave_Symlink checks that a symlinked config is written through the link, which stays in place.
+func TestSave_Symlink(t *testing.T) {
+	home, dotfiles := t.TempDir(), t.TempDir()
+	link, target := filepath.Join(home, "gommit.json"), filepath.Join(dotfiles, "gommit.json")
+	writeTestFile(t, target, `{"commit_style": "conventional"}`)
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	if err := Edit(link, func(raw map[string]any) error { return SetValue(raw, "commit_style", "simple") }); err != nil {
+		t.Fatalf("Edit failed: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("%s is no longer a symlink: %v", link, err)
+	}
+	if got := readTestFile(t, target); !strings.Contains(got, `"simple"`) {
+		t.Errorf("target after Edit:\n%s", got)
+	}
+	if _, err := os.Stat(target + ".bak"); err != nil {
+		t.Errorf("no backup next to the target: %v", err)
+	}
+
+	// a link to a file that does not exist yet creates the file
+	dangling := filepath.Join(home, "gommit.yaml")
+	if err := os.Symlink(filepath.Join(dotfiles, "gommit.yaml"), dangling); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(dangling, &types.Config{CommitStyle: "simple"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if info, err := os.Lstat(dangling); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("%s is no longer a symlink: %v", dangling, err)
+	}
+}
+
+// TestSave_Preserves checks that saving keeps the key order, unknown fields and YAML comments, and
+// removes the fields that were cleared.
+func TestSave_Preserves(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "gommit.json")
+	writeTestFile(t, jsonPath, `{
+  "$schema": "https://example.com/gommit.schema.json",
+  "providers": {
+    "openai": {"api_key": "x", "model": "gpt-4o-mini", "temperature": 1.0},
+    "ollama": {"uri": "http://localhost:11434", "model": "llama3", "temperature": 0.5}
+  },
+  "x_team": "platform",
+  "default_provider": "openai",
+  "signoff": true
+}
+`)
+	err := Edit(jsonPath, func(raw map[string]any) error {
+		for _, key := range []string{"providers.ollama", "signoff"} {
+			if err := UnsetValue(raw, key); err != nil {
+				return err
+			}
+		}
+		return SetValue(raw, "commit_style", "simple")
+	})
+	if err != nil {
+		t.Fatalf("Edit() failed: %v", err)
+	}
+	want := `{
+  "$schema": "https://example.com/gommit.schema.json",
+  "providers": {
+    "openai": {
+      "api_key": "x",
+      "model": "gpt-4o-mini",
+      "temperature": 1.0
+    }
+  },
+  "x_team": "platform",
+  "default_provider": "openai",
+  "commit_style": "simple",
+  "version": 3
+}
+`
+	if got := readTestFile(t, jsonPath); got != want {
+		t.Errorf("saved JSON:\n%s\nwant:\n%s", got, want)
+	}
+
+	yamlPath := filepath.Join(dir, "gommit.yaml")
+	writeTestFile(t, yamlPath, `# team defaults
+default_provider: ollama # local only
+providers:
+  ollama:
+    uri: http://localhost:11434
+    model: llama3 # pulled by the bootstrap script
+x_team: platform
+`)
+	err = Edit(yamlPath, func(raw map[string]any) error {
+		return SetValue(raw, "providers.ollama.model", "qwen2.5-coder")
+	})
+	if err != nil {
+		t.Fatalf("Edit() failed: %v", err)
+	}
+	got := readTestFile(t, yamlPath)
+	for _, line := range []string{
+		"# team defaults\ndefault_provider: ollama # local only\n",
+		"    model: qwen2.5-coder # pulled by the bootstrap script\n",
+		"x_team: platform\n",
+		"version: 3\n",
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("saved YAML is missing %q:\n%s", line, got)
+		}
+	}
+	if cfg, err := LoadFile(yamlPath); err != nil || cfg.Providers["ollama"].Model != "qwen2.5-coder" {
+		t.Errorf("LoadFile() = %v, %v", cfg, err)
+	}
+}
+
+// TestSave_Backups checks that each save keeps the previous content in a rotating backup.
+func TestSave_Backups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gommit.json")
+	for i := 1; i <= backups+2; i++ {
+		cfg := &types.Config{DefaultProvider: "openai", MaxLineWidth: i}
+		if err := Save(path, cfg); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+	}
+
+	for i, name := range []string{".bak", ".bak.1", ".bak.2"} {
+		cfg, err := LoadFile(path + name)
+		if err != nil {
+			t.Fatalf("LoadFile(%s) failed: %v", name, err)
+		}
+		if want := backups + 1 - i; cfg.MaxLineWidth != want {
+			t.Errorf("%s has max_line_width %d, want %d", name, cfg.MaxLineWidth, want)
+		}
+	}
+	if _, err := os.Stat(path + ".bak.3"); !os.IsNotExist(err) {
+		t.Errorf("more than %d backups kept", backups)
+	}
+
+	// Saving the same values does not rotate the backups
+	before := readTestFile(t, path+".bak")
+	if err := Save(path, &types.Config{DefaultProvider: "openai", MaxLineWidth: backups + 2}); err != nil {
+		t.Fatal(err)
+	}
+	if readTestFile(t, path+".bak") != before {
+		t.Error("an unchanged save rotated the backups")
+	}
+}
+
+// TestEdit_Concurrent checks that concurrent edits do not lose each other's changes.
+func TestEdit_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gommit.json")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := Edit(path, func(raw map[string]any) error {
+				trailers, _ := raw["trailers"].([]any)
+				raw["trailers"] = append(trailers, fmt.Sprintf("X-Run: %d", i))
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Edit() failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Trailers) != 10 {
+		t.Errorf("got %d trailers, want 10: %v", len(cfg.Trailers), cfg.Trailers)
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// TestSetValue checks setting, reading and removing dotted keys, and the schema checks on the way.
+func TestSetValue(t *testing.T) {
+	raw := map[string]any{}
+	for _, kv := range [][2]string{
+		{"providers.ollama.uri", "http://localhost:11434"},
+		{"providers.ollama.model", "llama3"},
+		{"providers.ollama.temperature", "0"},
+		{"default_provider", "ollama"},
+		{"lint.types", "feat, fix"},
+		{"signoff", "true"},
+		{"usage.prices", `{"llama3": {"input": 0, "output": 0}}`},
+	} {
+		if err := SetValue(raw, kv[0], kv[1]); err != nil {
+			t.Fatalf("SetValue(%s) failed: %v", kv[0], err)
+		}
+	}
+	cfg, err := Decode(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Providers["ollama"].URI != "http://localhost:11434" || !cfg.Signoff || !reflect.DeepEqual(cfg.Lint.Types, []string{"feat", "fix"}) {
+		t.Errorf("SetValue() gave %+v", cfg)
+	}
+	if v, ok, err := GetValue(raw, "providers.ollama.model"); err != nil || !ok || v != "llama3" {
+		t.Errorf("GetValue() = %v, %v, %v", v, ok, err)
+	}
+
+	for key, value := range map[string]string{
+		"comit_style":                  "simple",
+		"commit_style":                 "simpel",
+		"providers.mistral.model":      "x",
+		"providers.ollama.temperature": "2",
+		"lint.max_retries":             "many",
+		"history.exampels":             "3",
+	} {
+		if err := SetValue(raw, key, value); err == nil {
+			t.Errorf("SetValue(%s, %s) succeeded, want an error", key, value)
+		}
+	}
+	if err := SetValue(raw, "history.exampels", "3"); err == nil || !strings.Contains(err.Error(), `"history.examples"`) {
+		t.Errorf("SetValue() error = %v, want a suggestion for the full key", err)
+	}
+
+	if err := UnsetValue(raw, "lint.types"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := GetValue(raw, "lint.types"); ok {
+		t.Error("lint.types is still set")
+	}
+	if _, ok := raw["lint"]; ok {
+		t.Error("the empty lint section is still set")
+	}
+
+	cfg, err = Decode(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, err := Settings(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, s := range settings {
+		keys = append(keys, s.Key+"="+s.String())
+	}
+	want := "version=3 default_provider=ollama providers.ollama.uri=http://localhost:11434 providers.ollama.model=llama3 " +
+		"providers.ollama.temperature=0 signoff=true usage.prices.llama3.input=0 usage.prices.llama3.output=0"
+	if strings.Join(keys, " ") != want {
+		t.Errorf("Settings() = %s\nwant %s", strings.Join(keys, " "), want)
+	}
+}
+
+// TestEdit_WritesOnlyTheKey checks that set and unset change a single key of the file: unsetting
+// removes it, and setting does not write the zero values of its neighbours.
+func TestEdit_WritesOnlyTheKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gommit.json")
+	writeTestFile(t, path, `{
+  "providers": {"openai": {"api_key": "x", "model": "gpt-4o-mini", "temperature": 0.2}},
+  "default_provider": "openai"
+}`)
+
+	if err := Edit(path, func(raw map[string]any) error { return UnsetValue(raw, "providers.openai.temperature") }); err != nil {
+		t.Fatalf("unset failed: %v", err)
+	}
+	if got := readTestFile(t, path); strings.Contains(got, "temperature") {
+		t.Errorf("temperature is still in the file:\n%s", got)
+	}
+	raw, err := ReadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := GetValue(raw, "providers.openai.temperature"); ok {
+		t.Error("GetValue() still finds the unset temperature")
+	}
+
+	if err := Edit(path, func(raw map[string]any) error { return SetValue(raw, "providers.ollama.uri", "http://localhost:11434") }); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	got := readTestFile(t, path)
+	if strings.Contains(got, `"model": ""`) || strings.Contains(got, "temperature") || !strings.Contains(got, `"uri": "http://localhost:11434"`) {
+		t.Errorf("set wrote more than the key:\n%s", got)
+	}
+}
+
+// TestLoadEffective checks that the user config is merged over the system config, leaf by leaf,
+// and that locked keys keep the system value.
+func TestLoadEffective(t *testing.T) {
+	home, etc := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GOMMIT_SYSTEM_CONFIG", filepath.Join(etc, "config.yaml"))
+
+	writeTestFile(t, filepath.Join(etc, "config.yaml"), `
+default_provider: ollama
+commit_style: conventional
+providers:
+  ollama:
+    uri: http://localhost:11434
+    model: llama3
+    temperature: 0.2
+locked: [default_provider, providers.ollama.uri]
+`)
+	writeTestFile(t, filepath.Join(home, "gommit.json"), `{
+  "default_provider": "openai",
+  "commit_style": "simple",
+  "providers": {"ollama": {"uri": "https://ollama.example.com", "model": "mistral"}}
+}`)
+
+	eff, err := LoadEffective(true)
+	if err != nil {
+		t.Fatalf("LoadEffective failed: %v", err)
+	}
+	cfg := eff.Config
+	ollama := cfg.Providers["ollama"]
+	if cfg.DefaultProvider != "ollama" || cfg.CommitStyle != "simple" ||
+		ollama.URI != "http://localhost:11434" || ollama.Model != "mistral" || ollama.Temperature != 0.2 {
+		t.Errorf("merged config = %+v", cfg)
+	}
+	for key, want := range map[string]string{
+		"commit_style":                 LayerUser,
+		"providers.ollama.model":       LayerUser,
+		"providers.ollama.temperature": LayerSystem,
+		"default_provider":             LayerSystem,
+		"providers.ollama.uri":         LayerSystem,
+	} {
+		if got := eff.Origins[key]; got != want {
+			t.Errorf("origin of %s = %q, want %q", key, got, want)
+		}
+	}
+	var ignored []string
+	for _, ig := range eff.Ignored {
+		ignored = append(ignored, ig.Layer+":"+ig.Key+":"+ig.LockedBy)
+	}
+	if want := []string{"user:default_provider:system", "user:providers.ollama.uri:system"}; !reflect.DeepEqual(ignored, want) {
+		t.Errorf("ignored = %v, want %v", ignored, want)
+	}
+	if owner, ok := eff.LockOwner("providers.ollama.uri"); !ok || owner != LayerSystem {
+		t.Errorf("LockOwner(providers.ollama.uri) = %q, %v", owner, ok)
+	}
+	if _, ok := eff.LockOwner("providers.ollama.model"); ok {
+		t.Error("providers.ollama.model is not locked")
+	}
+
+	// only the system and shared config may lock keys
+	writeTestFile(t, filepath.Join(home, "gommit.json"), `{"locked": ["commit_style"]}`)
+	var invalid *InvalidError
+	if _, err := LoadEffective(true); !errors.As(err, &invalid) {
+		t.Errorf("LoadEffective with a user lock: error = %v, want *InvalidError", err)
+	}
+	if eff, err := LoadEffective(false); err != nil || len(eff.Config.Locked) != 2 {
+		t.Errorf("LoadEffective(false) = %+v, %v", eff, err)
+	}
+
+	// the system config alone is enough
+	if err := os.Remove(filepath.Join(home, "gommit.json")); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := Load(); err != nil || cfg.DefaultProvider != "ollama" {
+		t.Errorf("Load() with only a system config = %+v, %v", cfg, err)
+	}
+}
+
+// TestLoadEffective_Shared checks a shared config served over HTTP: its place between the system
+// and user config, its locks, and the cached copy used when the server is down.
+func TestLoadEffective_Shared(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("GOMMIT_SYSTEM_CONFIG", filepath.Join(t.TempDir(), "none.json"))
+
+	up := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"commit_style": "conventional", "max_line_width": 72, "locked": ["max_line_width"]}`))
+	}))
+	defer server.Close()
+
+	writeTestFile(t, filepath.Join(home, "gommit.json"), `{
+  "providers": {"openai": {"api_key": "x", "model": "gpt-4o-mini", "temperature": 0.7}},
+  "commit_style": "simple",
+  "max_line_width": 100,
+  "shared": {"source": "`+server.URL+`/gommit.json", "refresh_hours": 1}
+}`)
+
+	check := func(when string) {
+		t.Helper()
+		eff, err := LoadEffective(true)
+		if err != nil {
+			t.Fatalf("%s: LoadEffective failed: %v", when, err)
+		}
+		if eff.Config.CommitStyle != "simple" || eff.Config.MaxLineWidth != 72 || eff.LockedBy["max_line_width"] != LayerShared {
+			t.Errorf("%s: merged config = %+v", when, eff.Config)
+		}
+		if len(eff.Warnings) > 0 {
+			t.Errorf("%s: warnings = %v", when, eff.Warnings)
+		}
+	}
+	check("fetched")
+
+	up = false
+	check("cached")
+
+	cached, err := sharedCachePath(server.URL + "/gommit.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(cached, old, old); err != nil {
+		t.Fatal(err)
+	}
+	check("stale copy while the server is down")
+
+	if err := os.Remove(cached); err != nil {
+		t.Fatal(err)
+	}
+	eff, err := LoadEffective(true)
+	if err != nil || len(eff.Warnings) != 1 || eff.Config.MaxLineWidth != 100 {
+		t.Errorf("without a copy: %+v, %v", eff, err)
+	}
+}
+
+// TestLoadEffective_SharedFromSystemUnreachable checks that a shared source set by the system config
+// cannot be skipped by strict loads, so its locks do not disappear offline.
+func TestLoadEffective_SharedFromSystemUnreachable(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	system := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv("GOMMIT_SYSTEM_CONFIG", system)
+	writeTestFile(t, system, `{"shared": {"source": "`+server.URL+`/gommit.json"}, "locked": ["shared"]}`)
+	writeTestFile(t, filepath.Join(home, "gommit.json"), `{
+  "providers": {"openai": {"api_key": "x", "model": "gpt-4o-mini", "temperature": 0.7}},
+  "commit_style": "simple"
+}`)
+
+	if _, err := LoadEffective(true); err == nil {
+		t.Fatal("strict LoadEffective should fail without the shared config")
+	}
+	eff, err := LoadEffective(false)
+	if err != nil || len(eff.Warnings) != 1 {
+		t.Errorf("non-strict: %+v, %v", eff, err)
+	}
+}
+
+// TestLoadEffective_UnknownFields checks that strict loads warn about unknown fields, with a
+// suggestion for typos, and still fail on values of the wrong type.
+func TestLoadEffective_UnknownFields(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GOMMIT_SYSTEM_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+	path := filepath.Join(home, "gommit.json")
+
+	writeTestFile(t, path, `{
+  "providers": {"openai": {"api_key": "x", "model": "gpt-4o-mini"}},
+  "comit_style": "simple"
+}`)
+	eff, err := LoadEffective(true)
+	if err != nil {
+		t.Fatalf("LoadEffective failed: %v", err)
+	}
+	want := path + `: line 3, column 3: comit_style: unknown field (did you mean "commit_style"?) (ignored)`
+	if len(eff.Warnings) != 1 || eff.Warnings[0] != want {
+		t.Errorf("warnings = %q, want %q", eff.Warnings, want)
+	}
+
+	writeTestFile(t, path, `{
+  "providers": {"openai": {"api_key": "x", "model": "gpt-4o-mini"}},
+  "comit_style": "simple",
+  "max_line_width": "72"
+}`)
+	var invalid *InvalidError
+	if _, err := LoadEffective(true); !errors.As(err, &invalid) || len(invalid.Problems) != 1 || invalid.Problems[0].Field != "max_line_width" {
+		t.Errorf("LoadEffective() = %v, want only the max_line_width error", err)
+	}
+}
+
+// TestCheck_Policies checks the problems reported for policy rules.
+func TestCheck_Policies(t *testing.T) {
+	data := []byte(`{
+  "providers": {"ollama": {"uri": "http://localhost:11434", "model": "llama3"}},
+  "default_provider": "ollama",
+  "policies": [
+    {"remote": "github.com/acme/*", "allow": [{"provider": "ollama", "endpoint": "localhost"}]},
+    {"deny": [{}, {"provider": "olama"}], "block": true}
+  ]
+}`)
+	_, problems, err := Check(data)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.Field)
+	}
+	want := []string{"policies[1].block", "policies[1].deny[0]", "policies[1].deny[1].provider"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problem fields = %v, want %v", got, want)
+	}
+}
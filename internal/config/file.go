@@ -0,0 +1,449 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// backups is how many previous versions of the config file Save keeps (gommit.json.bak, .bak.1, ...)
+const backups = 3
+
+// isYAML reports whether a config file is YAML rather than JSON, from its extension
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// parseDocument parses a config file into a node tree that keeps the key order and, for YAML, the
+// comments. JSON files are checked with encoding/json first so syntax errors keep its messages.
+func parseDocument(path string, data []byte) (*yaml.Node, error) {
+	if isYAML(path) {
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 {
+			return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+		}
+		return doc.Content[0], nil
+	}
+
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, parseError(data, err)
+	}
+	return jsonNode(data)
+}
+
+// jsonNode builds the node tree of a valid JSON document. Keys and array elements get the line and
+// column where they start, like the nodes of a YAML document.
+func jsonNode(data []byte) (*yaml.Node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	// start returns the position of the next token, skipping whitespace and separators
+	start := func() (int, int) {
+		off := int(dec.InputOffset())
+		for off < len(data) && strings.IndexByte(" \t\r\n,:", data[off]) >= 0 {
+			off++
+		}
+		return lineColumn(data, off)
+	}
+
+	var value func() (*yaml.Node, error)
+	value = func() (*yaml.Node, error) {
+		line, column := start()
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch v := tok.(type) {
+		case json.Delim:
+			node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: line, Column: column}
+			if v == '[' {
+				node.Kind, node.Tag = yaml.SequenceNode, "!!seq"
+			}
+			for dec.More() {
+				if node.Kind == yaml.MappingNode {
+					line, column := start()
+					key, err := dec.Token()
+					if err != nil {
+						return nil, err
+					}
+					node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string), Line: line, Column: column})
+				}
+				child, err := value()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, child)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return node, nil
+		case string:
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v, Line: line, Column: column}, nil
+		case json.Number:
+			tag := "!!int"
+			if strings.ContainsAny(v.String(), ".eE") {
+				tag = "!!float"
+			}
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String(), Line: line, Column: column}, nil
+		case bool:
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v), Line: line, Column: column}, nil
+		default:
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null", Line: line, Column: column}, nil
+		}
+	}
+	return value()
+}
+
+// nodeValue converts a node tree to the values encoding/json decodes into an any: objects,
+// arrays, strings, float64 numbers, booleans and nil
+func nodeValue(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return nodeValue(node.Alias)
+	case yaml.MappingNode:
+		obj := make(map[string]any, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v, err := nodeValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			obj[node.Content[i].Value] = v
+		}
+		return obj, nil
+	case yaml.SequenceNode:
+		arr := make([]any, 0, len(node.Content))
+		for _, item := range node.Content {
+			v, err := nodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	}
+
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var b bool
+		err := node.Decode(&b)
+		return b, err
+	case "!!int", "!!float":
+		var f float64
+		err := node.Decode(&f)
+		return f, err
+	}
+	return node.Value, nil
+}
+
+// nodePositions maps every field path (as used in Problem.Field) to the line and column where its
+// key, or its array element, starts
+func nodePositions(root *yaml.Node) map[string][2]int {
+	positions := map[string][2]int{}
+	var walk func(node *yaml.Node, path string)
+	walk = func(node *yaml.Node, path string) {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i]
+				child := joinField(path, key.Value)
+				positions[child] = [2]int{key.Line, key.Column}
+				walk(node.Content[i+1], child)
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				child := fmt.Sprintf("%s[%d]", path, i)
+				positions[child] = [2]int{item.Line, item.Column}
+				walk(item, child)
+			}
+		}
+	}
+	walk(root, "")
+	return positions
+}
+
+// mergeNode updates dst in place with the values of src, a node tree encoded from a value of type
+// typ. Keys of dst keep their order and comments; keys only in src are appended. A key missing from
+// src is removed when typ knows it (the value was cleared or, for maps, deleted) and kept otherwise,
+// so fields this version of gommit does not know survive a save.
+func mergeNode(dst, src *yaml.Node, typ reflect.Type) {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if dst.Kind != src.Kind || (dst.Kind != yaml.MappingNode && dst.Kind != yaml.ScalarNode) {
+		replaceNode(dst, src)
+		return
+	}
+	if dst.Kind == yaml.ScalarNode {
+		if a, err := nodeValue(dst); err == nil {
+			if b, err := nodeValue(src); err == nil && a == b {
+				return // keeps 1.0, quoting and the like as written
+			}
+		}
+		if dst.Tag != src.Tag {
+			dst.Style = src.Style
+		}
+		dst.Tag, dst.Value = src.Tag, src.Value
+		return
+	}
+
+	fields := map[string]reflect.Type{}
+	if typ != nil && typ.Kind() == reflect.Struct {
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			fields[name] = typ.Field(i).Type
+		}
+	}
+	childType := func(key string) (reflect.Type, bool) {
+		if typ != nil && typ.Kind() == reflect.Map {
+			return typ.Elem(), true
+		}
+		t, ok := fields[key]
+		return t, ok
+	}
+
+	values := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		values[src.Content[i].Value] = src.Content[i+1]
+	}
+
+	content := dst.Content[:0:0]
+	for i := 0; i+1 < len(dst.Content); i += 2 {
+		key, value := dst.Content[i], dst.Content[i+1]
+		t, known := childType(key.Value)
+		newValue, ok := values[key.Value]
+		switch {
+		case ok:
+			mergeNode(value, newValue, t)
+			delete(values, key.Value)
+			if known && isEmptyMapping(value) {
+				continue // a section whose last value was cleared
+			}
+		case known:
+			continue
+		}
+		content = append(content, key, value)
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		value, ok := values[src.Content[i].Value]
+		if ok && !isEmptyMapping(value) {
+			content = append(content, src.Content[i], value)
+		}
+	}
+	dst.Content = content
+}
+
+// isEmptyMapping reports whether node is an object without any non-empty value, like the sections
+// of types.Config left at their zero value
+func isEmptyMapping(node *yaml.Node) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 1; i < len(node.Content); i += 2 {
+		if !isEmptyMapping(node.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// replaceNode replaces dst with src, keeping the comments attached to dst
+func replaceNode(dst, src *yaml.Node) {
+	head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+	*dst = *src
+	dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+}
+
+// encodeDocument serializes a node tree in the format of path. JSON uses the indentation of the
+// previous file (four spaces for a new one).
+func encodeDocument(path string, root *yaml.Node, previous []byte) ([]byte, error) {
+	if isYAML(path) {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(root); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, root, jsonIndent(previous), ""); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// jsonIndent returns the indentation unit of a JSON document
+func jsonIndent(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "    "
+}
+
+func writeJSON(buf *bytes.Buffer, node *yaml.Node, indent, prefix string) error {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return writeJSON(buf, node.Alias, indent, prefix)
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, end, step := "{", "}", 2
+		if node.Kind == yaml.SequenceNode {
+			open, end, step = "[", "]", 1
+		}
+		if len(node.Content) == 0 {
+			buf.WriteString(open + end)
+			return nil
+		}
+		buf.WriteString(open)
+		for i := 0; i < len(node.Content); i += step {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString("\n" + prefix + indent)
+			if step == 2 {
+				buf.Write(jsonString(node.Content[i].Value))
+				buf.WriteString(": ")
+			}
+			if err := writeJSON(buf, node.Content[i+step-1], indent, prefix+indent); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("\n" + prefix + end)
+		return nil
+	}
+
+	switch node.ShortTag() {
+	case "!!null", "!!bool", "!!int", "!!float":
+		if json.Valid([]byte(node.Value)) {
+			buf.WriteString(node.Value) // keeps 1.0 as written
+			return nil
+		}
+		v, err := nodeValue(node)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		return nil
+	}
+	buf.Write(jsonString(node.Value))
+	return nil
+}
+
+// jsonString quotes s without escaping <, > and &
+func jsonString(s string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// maxLinks bounds the symlinks followed by resolveLinks
+const maxLinks = 40
+
+// resolveLinks follows the symlinks of path to the file they point to, even when it does not exist
+// yet, so a dotfile-managed config is written in place instead of being replaced by a regular file.
+// The lock and the backups live next to the real file as well.
+func resolveLinks(path string) string {
+	for range maxLinks {
+		target, err := os.Readlink(path)
+		if err != nil {
+			break
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+// writeFile replaces path with data atomically: the data goes to a temporary file in the same
+// directory, which is renamed over path once it is synced. The previous content is kept in the
+// rotating backups first.
+func writeFile(path string, data, previous []byte) error {
+	if previous != nil {
+		if err := rotateBackups(path, previous); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// rotateBackups shifts path.bak to path.bak.1 (and so on, up to backups files) and saves previous
+// as path.bak
+func rotateBackups(path string, previous []byte) error {
+	name := func(i int) string {
+		if i == 0 {
+			return path + ".bak"
+		}
+		return fmt.Sprintf("%s.bak.%d", path, i)
+	}
+	for i := backups - 1; i > 0; i-- {
+		if err := os.Rename(name(i-1), name(i)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.WriteFile(name(0), previous, 0o600)
+}
+
+// How long lockFile waits for another gommit to release the config file
+const (
+	lockTimeout = 10 * time.Second
+	lockRetry   = 50 * time.Millisecond
+)
+
+var errLocked = errors.New("the config file is being changed by another gommit process")
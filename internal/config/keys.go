@@ -0,0 +1,253 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// Setting is a value of the configuration file under its dotted key, e.g. providers.ollama.uri
+type Setting struct {
+	Key   string
+	Value any
+}
+
+// String formats the value for `gommit config get|list`: strings as they are, everything else as JSON
+func (s Setting) String() string {
+	if str, ok := s.Value.(string); ok {
+		return str
+	}
+	data, _ := json.Marshal(s.Value)
+	return string(data)
+}
+
+// Settings lists the values set in cfg as dotted keys, in the order of the file format. Arrays are
+// single values; empty sections are left out.
+func Settings(cfg *types.Config) ([]Setting, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	root, err := jsonNode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings []Setting
+	var walk func(node *yaml.Node, key string) error
+	walk = func(node *yaml.Node, key string) error {
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if err := walk(node.Content[i+1], joinField(key, node.Content[i].Value)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		value, err := nodeValue(node)
+		if err != nil {
+			return err
+		}
+		settings = append(settings, Setting{Key: key, Value: value})
+		return nil
+	}
+	if err := walk(root, ""); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// GetValue returns the value of a dotted key in the settings of a file (see ReadSettings); ok is
+// false when the key is not set. The key must exist in the schema.
+func GetValue(raw map[string]any, key string) (value any, ok bool, err error) {
+	if _, err := schemaAt(key); err != nil {
+		return nil, false, err
+	}
+
+	var cur any = raw
+	for _, part := range strings.Split(key, ".") {
+		obj, isObj := cur.(map[string]any)
+		if !isObj {
+			return nil, false, nil
+		}
+		if cur, ok = obj[part]; !ok {
+			return nil, false, nil
+		}
+	}
+	return cur, true, nil
+}
+
+// SetValue sets a dotted key in the settings of a file from its command-line form: numbers and
+// booleans are parsed, arrays are given as JSON or as a comma-separated list, objects as JSON. The
+// value is checked against the schema before raw is changed.
+func SetValue(raw map[string]any, key, value string) error {
+	node, err := schemaAt(key)
+	if err != nil {
+		return err
+	}
+	v, err := parseValue(node, value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	if problems := checkKey(key, v); len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	setPath(raw, key, v)
+	return nil
+}
+
+// UnsetValue removes a dotted key from the settings of a file, so a lower layer or the default
+// applies again. Sections left empty are removed too.
+func UnsetValue(raw map[string]any, key string) error {
+	if _, err := schemaAt(key); err != nil {
+		return err
+	}
+	var unset func(obj map[string]any, parts []string)
+	unset = func(obj map[string]any, parts []string) {
+		if len(parts) == 1 {
+			delete(obj, parts[0])
+			return
+		}
+		child, ok := obj[parts[0]].(map[string]any)
+		if !ok {
+			return
+		}
+		unset(child, parts[1:])
+		if len(child) == 0 {
+			delete(obj, parts[0])
+		}
+	}
+	unset(raw, strings.Split(key, "."))
+	return nil
+}
+
+// checkKey validates a value against the schema of its dotted key
+func checkKey(key string, value any) []string {
+	obj := map[string]any{}
+	setPath(obj, key, value)
+
+	// only the changed key is checked, so a problem elsewhere in the file does not block the change
+	var problems []string
+	rootSchema.check(obj, "", func(p Problem) {
+		if p.Field == key || strings.HasPrefix(p.Field, key+".") || strings.HasPrefix(p.Field, key+"[") {
+			problems = append(problems, p.Error())
+		}
+	})
+	return problems
+}
+
+// schemaAt returns the schema of a dotted key, or an error naming the closest valid key
+func schemaAt(key string) (*schemaNode, error) {
+	if key == "" {
+		return nil, fmt.Errorf("empty key")
+	}
+	node := rootSchema
+	path := ""
+	for _, part := range strings.Split(key, ".") {
+		node = node.resolve()
+		if node.Type != "object" {
+			return nil, fmt.Errorf("unknown key %q: %s is not an object", key, path)
+		}
+		if prop, ok := node.Properties[part]; ok {
+			node, path = prop, joinField(path, part)
+			continue
+		}
+		if node.PropertyNames != nil && len(node.PropertyNames.Enum) > 0 {
+			names := enumStrings(node.PropertyNames.Enum)
+			if !slices.Contains(names, part) {
+				return nil, fmt.Errorf("unknown key %q: %s%s (expected one of: %s)", key, joinField(path, part), suggestion(part, names), strings.Join(names, ", "))
+			}
+		}
+		additional, _ := node.additional()
+		if additional == nil {
+			return nil, fmt.Errorf("unknown key %q%s", key, keySuggestion(path, part, node.propertyNames()))
+		}
+		node, path = additional, joinField(path, part)
+	}
+	return node.resolve(), nil
+}
+
+// keySuggestion suggests the full dotted key for a typo in its last part
+func keySuggestion(path, part string, candidates []string) string {
+	s := suggestion(part, candidates)
+	if s == "" || path == "" {
+		return s
+	}
+	return strings.Replace(s, `"`, `"`+path+".", 1)
+}
+
+// parseValue converts a command-line value to the JSON type the schema node expects
+func parseValue(node *schemaNode, value string) (any, error) {
+	switch node.Type {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not true or false", value)
+		}
+		return b, nil
+	case "array":
+		if strings.HasPrefix(strings.TrimSpace(value), "[") {
+			var arr []any
+			if err := json.Unmarshal([]byte(value), &arr); err != nil {
+				return nil, fmt.Errorf("invalid JSON array: %w", err)
+			}
+			return arr, nil
+		}
+		arr := []any{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				v, err := parseValue(node.Items.resolve(), item)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, v)
+			}
+		}
+		return arr, nil
+	case "object":
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(value), &obj); err != nil {
+			return nil, fmt.Errorf("expected a JSON object: %w", err)
+		}
+		return obj, nil
+	}
+	return value, nil
+}
+
+// Keys returns every dotted key of the schema, for the help. Entries of a map are shown as
+// <name>, e.g. providers.<name>.model.
+func Keys() []string {
+	var keys []string
+	var walk func(node *schemaNode, path string)
+	walk = func(node *schemaNode, path string) {
+		node = node.resolve()
+		if node.Type != "object" {
+			keys = append(keys, path)
+			return
+		}
+		for _, name := range node.propertyNames() {
+			if name != "$schema" {
+				walk(node.Properties[name], joinField(path, name))
+			}
+		}
+		if additional, _ := node.additional(); additional != nil {
+			walk(additional, joinField(path, "<name>"))
+		}
+	}
+	walk(rootSchema, "")
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,296 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// Names of the configuration layers, from the lowest precedence to the highest
+const (
+	LayerDefault = "default"
+	LayerSystem  = "system"
+	LayerShared  = "shared"
+	LayerUser    = "user"
+)
+
+// Layer is one configuration source that exists on this machine
+type Layer struct {
+	Name   string
+	Path   string // file, URL or git source
+	Cached string // local copy of a shared source
+	raw    map[string]any
+
+	warnings []Problem // unknown fields, which are ignored
+}
+
+// Ignored is a value of a layer that a lower layer locked
+type Ignored struct {
+	Setting
+	Layer    string
+	LockedBy string
+}
+
+// Effective is the configuration merged from every layer, with the origin of each value
+type Effective struct {
+	Config   *types.Config
+	Layers   []Layer
+	Origins  map[string]string // dotted key -> layer name; keys not listed come from the defaults
+	LockedBy map[string]string // locked entry -> layer that locked it
+	Ignored  []Ignored
+	Warnings []string
+}
+
+// SystemConfigPath returns the path of the system-wide configuration: $GOMMIT_SYSTEM_CONFIG when set,
+// otherwise /etc/gommit/config.json (config.yaml when that exists instead), or
+// %ProgramData%\gommit\config.json on Windows
+func SystemConfigPath() string {
+	if path := os.Getenv("GOMMIT_SYSTEM_CONFIG"); path != "" {
+		return path
+	}
+	dir := "/etc/gommit"
+	if runtime.GOOS == "windows" {
+		dir = filepath.Join(os.Getenv("ProgramData"), "gommit")
+	}
+	for _, name := range []string{"config.yaml", "config.yml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name)
+		}
+	}
+	return filepath.Join(dir, "config.json")
+}
+
+// LoadEffective reads and merges the system, shared and user configuration. With strict set, any
+// problem in a layer or in the merged result is an *InvalidError; otherwise only unreadable files
+// fail. It returns ErrNotFound when none of the layers exists.
+func LoadEffective(strict bool) (*Effective, error) {
+	eff := &Effective{Origins: map[string]string{}, LockedBy: map[string]string{}}
+
+	system, err := readLayer(LayerSystem, SystemConfigPath(), strict)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	user, err := readLayer(LayerUser, GetConfigPath(), strict)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if user != nil {
+		if _, ok := user.raw["locked"]; ok {
+			if strict {
+				return nil, &InvalidError{Path: user.Path, Problems: []Problem{{Field: "locked", Message: "only the system or shared config can lock keys"}}}
+			}
+			delete(user.raw, "locked")
+		}
+	}
+
+	var shared *Layer
+	if source, refresh, from := sharedSource(system, user); source != "" {
+		shared, err = fetchShared(source, refresh)
+		if err != nil {
+			// a shared config named by the system config may carry the team's locks: without it
+			// nothing would be locked, so strict loads refuse to go on
+			if strict && from == LayerSystem {
+				return nil, fmt.Errorf("could not load shared config %s set by %s: %w", source, system.Path, err)
+			}
+			eff.Warnings = append(eff.Warnings, fmt.Sprintf("shared config %s skipped: %v", source, err))
+		}
+	}
+
+	if system == nil && shared == nil && user == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, GetConfigPath())
+	}
+
+	merged := map[string]any{}
+	locked := &types.Config{}
+	for _, layer := range []*Layer{system, shared, user} {
+		if layer == nil {
+			continue
+		}
+		eff.Layers = append(eff.Layers, *layer)
+		for _, p := range layer.warnings {
+			eff.Warnings = append(eff.Warnings, fmt.Sprintf("%s: %s (ignored)", layer.Path, p))
+		}
+		for _, s := range flatten(layer.raw, "") {
+			if locked.IsLocked(s.Key) {
+				eff.Ignored = append(eff.Ignored, Ignored{Setting: s, Layer: layer.Name, LockedBy: lockOwner(eff.LockedBy, s.Key)})
+				continue
+			}
+			setPath(merged, s.Key, s.Value)
+			eff.Origins[s.Key] = layer.Name
+		}
+		if layer.Name != LayerUser {
+			for _, entry := range stringList(layer.raw["locked"]) {
+				if _, ok := eff.LockedBy[entry]; !ok {
+					eff.LockedBy[entry] = layer.Name
+					locked.Locked = append(locked.Locked, entry)
+				}
+			}
+		}
+	}
+	merged["locked"] = stringSlice(locked.Locked)
+	merged["version"] = float64(CurrentVersion) // every layer is migrated already
+
+	cfg, err := decode(merged)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Locked = locked.Locked
+	if strict {
+		if problems := Validate(cfg); len(problems) > 0 {
+			path := GetConfigPath()
+			if user == nil {
+				path = SystemConfigPath()
+			}
+			return nil, &InvalidError{Path: path, Problems: problems}
+		}
+	}
+	eff.Config = cfg
+	return eff, nil
+}
+
+// readLayer reads one configuration file, migrated to the current version. With strict set, its
+// schema errors are an *InvalidError; unknown fields are only warnings.
+func readLayer(name, path string, strict bool) (*Layer, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw, problems, err := parseLayer(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	errs, warnings := splitWarnings(problems)
+	if strict && len(errs) > 0 {
+		return nil, &InvalidError{Path: path, Problems: errs}
+	}
+	return &Layer{Name: name, Path: path, raw: raw, warnings: warnings}, nil
+}
+
+// sharedSource returns the shared source set by the user config, or by the system config when
+// the user config does not set one or the system config locked it, and the layer that set it
+func sharedSource(system, user *Layer) (string, int, string) {
+	var source, from string
+	var refresh int
+	locked := &types.Config{}
+	for _, layer := range []*Layer{system, user} {
+		if layer == nil {
+			continue
+		}
+		shared, _ := layer.raw["shared"].(map[string]any)
+		if s, ok := shared["source"].(string); ok && !locked.IsLocked("shared.source") {
+			source, from = s, layer.Name
+		}
+		if n, ok := shared["refresh_hours"].(float64); ok && !locked.IsLocked("shared.refresh_hours") {
+			refresh = int(n)
+		}
+		locked.Locked = stringList(layer.raw["locked"])
+	}
+	return source, refresh, from
+}
+
+// lockOwner returns the layer that locked key
+func lockOwner(lockedBy map[string]string, key string) string {
+	entries := make([]string, 0, len(lockedBy))
+	for entry := range lockedBy {
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+	for _, entry := range entries {
+		if (&types.Config{Locked: []string{entry}}).IsLocked(key) {
+			return lockedBy[entry]
+		}
+	}
+	return ""
+}
+
+// flatten lists the leaf values of a config object as dotted keys; arrays are leaves
+func flatten(obj map[string]any, prefix string) []Setting {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var settings []Setting
+	for _, k := range keys {
+		key := joinField(prefix, k)
+		if k == "locked" && prefix == "" {
+			continue // merged separately
+		}
+		if child, ok := obj[k].(map[string]any); ok {
+			settings = append(settings, flatten(child, key)...)
+			continue
+		}
+		settings = append(settings, Setting{Key: key, Value: obj[k]})
+	}
+	return settings
+}
+
+// setPath sets a dotted key in a config object, creating the objects on the way
+func setPath(obj map[string]any, key string, value any) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := obj[part].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			obj[part] = child
+		}
+		obj = child
+	}
+	obj[parts[len(parts)-1]] = value
+}
+
+func stringList(value any) []string {
+	var list []string
+	items, _ := value.([]any)
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+func stringSlice(values []string) []any {
+	out := make([]any, 0, len(values))
+	for _, v := range values {
+		out = append(out, v)
+	}
+	return out
+}
+
+// Describe lists the effective values with their origin, including the defaults gommit fills in
+func (e *Effective) Describe() ([]Setting, map[string]string, error) {
+	cfg := *e.Config
+	applyDefaults(&cfg)
+	settings, err := Settings(&cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	origins := make(map[string]string, len(settings))
+	for _, s := range settings {
+		if s.Key == "locked" {
+			continue
+		}
+		origin, ok := e.Origins[s.Key]
+		if !ok {
+			origin = LayerDefault
+		}
+		origins[s.Key] = origin
+	}
+	return settings, origins, nil
+}
+
+// LockOwner returns the layer that locked key, if any
+func (e *Effective) LockOwner(key string) (string, bool) {
+	if !e.Config.IsLocked(key) {
+		return "", false
+	}
+	return lockOwner(e.LockedBy, key), true
+}
@@ -0,0 +1,36 @@
+//go:build !unix
+
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// lockStale is the age after which a lock file left by a crashed gommit is ignored
+const lockStale = time.Minute
+
+// lockFile takes an exclusive lock on path by creating path.lock, waiting up to lockTimeout for
+// another gommit to remove it. Platforms without flock fall back to this lock file.
+func lockFile(path string) (func(), error) {
+	lock := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lock) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(lock); statErr == nil && time.Since(info.ModTime()) > lockStale {
+			_ = os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errLocked
+		}
+		time.Sleep(lockRetry)
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import "fmt"
+
+// CurrentVersion is the config file format written by this version of gommit. Files without a
+// "version" field are version 1.
+const CurrentVersion = 3
+
+// migrations upgrade a decoded config file one version at a time: migrations[i] turns version i+1
+// into version i+2. They work on the raw JSON object so fields unknown to types.Config survive.
+var migrations = []func(raw map[string]any){
+	// 1 -> 2: max_tokens was written by the wizard but never sent to the provider. It is now honoured,
+	// so it is dropped from older files rather than suddenly capping (or emptying, for reasoning
+	// models) the messages of existing setups.
+	func(raw map[string]any) {
+		delete(raw, "max_tokens")
+	},
+	// 2 -> 3: ticket references were taken from every branch unless ticket.disabled was set; they are
+	// now opt-in with ticket.enabled, so disabled has nothing left to turn off.
+	func(raw map[string]any) {
+		if t, ok := raw["ticket"].(map[string]any); ok {
+			delete(t, "disabled")
+			if len(t) == 0 {
+				delete(raw, "ticket")
+			}
+		}
+	},
+}
+
+// fileVersion returns the format version of a decoded config file
+func fileVersion(raw map[string]any) (int, error) {
+	v, ok := raw["version"]
+	if !ok {
+		return 1, nil
+	}
+	n, ok := v.(float64)
+	if !ok || n < 1 || n != float64(int(n)) {
+		return 0, fmt.Errorf("version must be a positive integer")
+	}
+	return int(n), nil
+}
+
+// migrate upgrades a decoded config file to CurrentVersion in place. It reports whether anything
+// changed, and fails for files written by a newer gommit.
+func migrate(raw map[string]any) (bool, error) {
+	version, err := fileVersion(raw)
+	if err != nil {
+		return false, err
+	}
+	if version > CurrentVersion {
+		return false, fmt.Errorf("config version %d is newer than this gommit supports (%d), please upgrade gommit", version, CurrentVersion)
+	}
+	for ; version < CurrentVersion; version++ {
+		migrations[version-1](raw)
+	}
+	changed := raw["version"] != float64(CurrentVersion)
+	raw["version"] = float64(CurrentVersion)
+	return changed, nil
+}
+
+// defaultTemperatures sets the default temperature of the providers that do not set one. This
+// happens on the raw file because an explicit 0 is a valid temperature and must be kept.
+func defaultTemperatures(raw map[string]any, temperature float64) {
+	providers, _ := raw["providers"].(map[string]any)
+	for _, p := range providers {
+		if pc, ok := p.(map[string]any); ok {
+			if _, set := pc["temperature"]; !set {
+				pc["temperature"] = temperature
+			}
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// Schema is the JSON Schema of the configuration file. Editors use it for completion when the file
+// sets "$schema"; Check uses it to find unknown fields, wrong types and out-of-range values.
+//
+//go:embed gommit.schema.json
+var Schema []byte
+
+// schemaNode is the subset of JSON Schema (draft-07) the configuration schema uses
+type schemaNode struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 string                 `json:"type"`
+	Enum                 []any                  `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	Properties           map[string]*schemaNode `json:"properties"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+	PropertyNames        *schemaNode            `json:"propertyNames"`
+	Items                *schemaNode            `json:"items"`
+	Definitions          map[string]*schemaNode `json:"definitions"`
+}
+
+var rootSchema = func() *schemaNode {
+	var root schemaNode
+	if err := json.Unmarshal(Schema, &root); err != nil {
+		panic(fmt.Sprintf("invalid embedded config schema: %v", err))
+	}
+	return &root
+}()
+
+// Check parses a JSON configuration file and validates it against Schema and Validate. A file that
+// is not valid JSON is returned as err (with its line and column); everything else is reported as
+// problems, each with the position of the offending field. The returned config is migrated to
+// CurrentVersion; it is nil when the file could not be decoded.
+func Check(data []byte) (*types.Config, []Problem, error) {
+	return CheckFile("gommit.json", data)
+}
+
+// CheckFile is Check for the file at path, which may be JSON or YAML
+func CheckFile(path string, data []byte) (*types.Config, []Problem, error) {
+	root, err := parseDocument(path, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, problems, err := checkDocument(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	positions := nodePositions(root)
+
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		return nil, locate(problems, positions), nil
+	}
+	cfg, err := decode(obj)
+	if err != nil {
+		if len(problems) == 0 {
+			return nil, nil, err
+		}
+		return nil, locate(problems, positions), nil
+	}
+
+	problems = append(problems, Validate(cfg)...)
+	return cfg, locate(problems, positions), nil
+}
+
+// parseLayer parses one configuration file, checks it against the schema and migrates it to
+// CurrentVersion, without filling in any default. The problems carry their position in the file.
+func parseLayer(path string, data []byte) (map[string]any, []Problem, error) {
+	root, err := parseDocument(path, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, problems, err := checkDocument(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	problems = locate(problems, nodePositions(root))
+
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		return nil, nil, fmt.Errorf("not an object")
+	}
+	if _, err := migrate(obj); err != nil {
+		return nil, nil, err
+	}
+	return obj, problems, nil
+}
+
+// checkDocument converts a parsed file to JSON values, migrates them and checks them against the
+// schema, so fields that only older versions had are not reported
+func checkDocument(root *yaml.Node) (any, []Problem, error) {
+	raw, err := nodeValue(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	if obj, ok := raw.(map[string]any); ok {
+		_, _ = migrate(obj) // a version that cannot be migrated is reported when the file is decoded
+	}
+	var problems []Problem
+	rootSchema.check(raw, "", func(p Problem) { problems = append(problems, p) })
+	return raw, problems, nil
+}
+
+// splitWarnings separates the problems that make a file unusable from the warnings
+func splitWarnings(problems []Problem) (errs, warnings []Problem) {
+	for _, p := range problems {
+		if p.Warning {
+			warnings = append(warnings, p)
+		} else {
+			errs = append(errs, p)
+		}
+	}
+	return errs, warnings
+}
+
+// check validates value against the schema node, reporting problems through report. Unknown fields
+// are warnings; wrong types and values are errors.
+func (s *schemaNode) check(value any, path string, report func(p Problem)) {
+	add := func(field, format string, args ...any) {
+		report(Problem{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	s = s.resolve()
+	field := path
+	if field == "" {
+		field = "(root)"
+	}
+
+	if !s.matchesType(value) {
+		add(field, "must be %s, not %s", article(s.Type), jsonType(value))
+		return
+	}
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, value) {
+		add(field, "unknown value %s (expected one of: %s)", formatValue(value), formatEnum(s.Enum))
+	}
+	if n, ok := value.(float64); ok {
+		if s.Minimum != nil && n < *s.Minimum {
+			add(field, "must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			add(field, "must be at most %v", *s.Maximum)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		additional, closed := s.additional()
+		for _, k := range keys {
+			child := joinField(path, k)
+			if prop, ok := s.Properties[k]; ok {
+				prop.check(v[k], child, report)
+				continue
+			}
+			if s.PropertyNames != nil && len(s.PropertyNames.Enum) > 0 && !slices.Contains(s.PropertyNames.Enum, any(k)) {
+				add(child, "unknown name%s (expected one of: %s)", suggestion(k, enumStrings(s.PropertyNames.Enum)), formatEnum(s.PropertyNames.Enum))
+				continue
+			}
+			switch {
+			case additional != nil:
+				additional.check(v[k], child, report)
+			case closed:
+				report(Problem{Field: child, Message: "unknown field" + suggestion(k, s.propertyNames()), Warning: true})
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.check(item, fmt.Sprintf("%s[%d]", path, i), report)
+			}
+		}
+	}
+}
+
+// resolve follows a local "$ref" (e.g. "#/definitions/provider")
+func (s *schemaNode) resolve() *schemaNode {
+	for s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/definitions/")
+		def, ok := rootSchema.Definitions[name]
+		if !ok {
+			panic(fmt.Sprintf("config schema: unresolved $ref %q", s.Ref))
+		}
+		s = def
+	}
+	return s
+}
+
+// additional returns the schema of properties not listed in Properties, and whether they are
+// forbidden ("additionalProperties": false)
+func (s *schemaNode) additional() (*schemaNode, bool) {
+	if len(s.AdditionalProperties) == 0 {
+		return nil, false
+	}
+	if string(s.AdditionalProperties) == "false" {
+		return nil, true
+	}
+	var node schemaNode
+	if err := json.Unmarshal(s.AdditionalProperties, &node); err != nil {
+		return nil, false
+	}
+	return &node, false
+}
+
+func (s *schemaNode) propertyNames() []string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *schemaNode) matchesType(value any) bool {
+	switch s.Type {
+	case "":
+		return true
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	}
+	return false
+}
+
+// jsonType names the JSON type of a decoded value for error messages
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "an integer"
+		}
+		return "a number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func article(schemaType string) string {
+	switch schemaType {
+	case "object", "array", "integer":
+		return "an " + schemaType
+	}
+	return "a " + schemaType
+}
+
+func formatValue(value any) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func formatEnum(values []any) string {
+	return strings.Join(enumStrings(values), ", ")
+}
+
+func enumStrings(values []any) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, fmt.Sprint(v))
+	}
+	return out
+}
+
+func joinField(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// suggestion returns ` (did you mean "x"?)` for the candidate closest to name, when one is close
+// enough to be a typo
+func suggestion(name string, candidates []string) string {
+	best, bestDistance := "", -1
+	for _, c := range candidates {
+		d := levenshtein(strings.ToLower(name), strings.ToLower(c))
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	if best == "" || bestDistance > max(2, len(name)/3) {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// locate sets the line and column of each problem from the position of its field, or of the
+// closest enclosing field present in the file
+func locate(problems []Problem, positions map[string][2]int) []Problem {
+	for i, p := range problems {
+		for field := p.Field; field != ""; field = parentField(field) {
+			if pos, ok := positions[field]; ok {
+				problems[i].Line, problems[i].Column = pos[0], pos[1]
+				break
+			}
+		}
+	}
+	return problems
+}
+
+func parentField(field string) string {
+	if i := strings.LastIndexAny(field, ".["); i >= 0 {
+		return field[:i]
+	}
+	return ""
+}
+
+// lineColumn converts a byte offset to a 1-based line and column
+func lineColumn(data []byte, offset int) (int, int) {
+	offset = min(offset, len(data))
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return line, column
+}
+
+// parseError adds the line and column to JSON decoding errors
+func parseError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// Offset counts the offending byte
+		off := max(int(syntaxErr.Offset)-1, 0)
+		line, col := lineColumn(data, off)
+		if off < len(data) && data[off] == '/' {
+			return fmt.Errorf("line %d, column %d: %w (JSON has no comments; use gommit.yaml to keep them)", line, col, err)
+		}
+		return fmt.Errorf("line %d, column %d: %w", line, col, err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		line, col := lineColumn(data, int(typeErr.Offset))
+		return fmt.Errorf("line %d, column %d: %s must be %s, not %s", line, col, typeErr.Field, typeErr.Type, typeErr.Value)
+	}
+	return err
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultSharedRefresh is how long a fetched shared config is used before it is fetched again
+const defaultSharedRefresh = 24 * time.Hour
+
+var sharedClient = &http.Client{Timeout: 10 * time.Second}
+
+// fetchShared returns the shared config layer of source: an http(s) URL, a git repository and the
+// path of the file in it ("git+https://host/team/dotfiles.git#gommit/config.json"), or a local
+// file. Remote sources are cached for refreshHours (0 means the default); when they cannot be
+// fetched, the last cached copy is used.
+func fetchShared(source string, refreshHours int) (*Layer, error) {
+	remote := strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "git+")
+	if !remote {
+		return readSharedLayer(source, source, source)
+	}
+
+	refresh := defaultSharedRefresh
+	if refreshHours > 0 {
+		refresh = time.Duration(refreshHours) * time.Hour
+	}
+	cached, err := sharedCachePath(source)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(cached); err == nil && time.Since(info.ModTime()) < refresh {
+		return readSharedLayer(source, cached, sharedFileName(source))
+	}
+
+	data, fetchErr := fetchSource(source, cached)
+	if fetchErr == nil {
+		if err := os.MkdirAll(filepath.Dir(cached), 0o700); err == nil {
+			fetchErr = writeFile(cached, data, nil)
+		}
+	}
+	if fetchErr != nil {
+		if _, err := os.Stat(cached); err != nil {
+			return nil, fetchErr
+		}
+		// stale, but better than dropping the team's settings (and locks) while offline; it is
+		// used for another refresh period so every run does not wait for the timeout
+		_ = os.Chtimes(cached, time.Now(), time.Now())
+	}
+	return readSharedLayer(source, cached, sharedFileName(source))
+}
+
+// readSharedLayer parses a shared config; name decides between JSON and YAML. Its schema errors
+// fail the load: a broken team config must be fixed at the source. Unknown fields are warnings, so
+// a team config written for a newer gommit still loads.
+func readSharedLayer(source, path, name string) (*Layer, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw, problems, err := parseLayer(name, data)
+	if err != nil {
+		return nil, err
+	}
+	errs, warnings := splitWarnings(problems)
+	if len(errs) > 0 {
+		return nil, &InvalidError{Path: source, Problems: errs}
+	}
+	layer := &Layer{Name: LayerShared, Path: source, raw: raw, warnings: warnings}
+	if path != source {
+		layer.Cached = path
+	}
+	return layer, nil
+}
+
+// fetchSource downloads a remote shared config
+func fetchSource(source, cached string) ([]byte, error) {
+	if strings.HasPrefix(source, "git+") {
+		repo, file, ok := strings.Cut(strings.TrimPrefix(source, "git+"), "#")
+		if !ok || file == "" {
+			return nil, fmt.Errorf("git source needs the path of the file after #")
+		}
+		return fetchGit(repo, file, cached+".git")
+	}
+
+	resp, err := sharedClient.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", source, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// fetchGit reads file from the default branch of repo, kept as a shallow clone in dir
+func fetchGit(repo, file, dir string) ([]byte, error) {
+	run := func(args ...string) error {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		if err := run("-C", dir, "fetch", "--quiet", "--depth", "1", "origin", "HEAD"); err != nil {
+			return nil, err
+		}
+		if err := run("-C", dir, "reset", "--quiet", "--hard", "FETCH_HEAD"); err != nil {
+			return nil, err
+		}
+	} else {
+		_ = os.RemoveAll(dir)
+		if err := run("clone", "--quiet", "--depth", "1", repo, dir); err != nil {
+			return nil, err
+		}
+	}
+	return os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+}
+
+// sharedCachePath returns where the copy of a remote source is kept
+func sharedCachePath(source string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(dir, "gommit", "shared", hex.EncodeToString(sum[:4])+filepath.Ext(sharedFileName(source))), nil
+}
+
+// sharedFileName returns the file name of a source, whose extension tells JSON from YAML
+func sharedFileName(source string) string {
+	if _, file, ok := strings.Cut(source, "#"); ok {
+		return file
+	}
+	name, _, _ := strings.Cut(source, "?")
+	if ext := filepath.Ext(name); ext != ".yaml" && ext != ".yml" {
+		return "shared.json"
+	}
+	return name
+}
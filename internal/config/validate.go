@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/edhuardotierrez/gommit/internal/llm"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// CommitStyles are the commit styles gommit can generate
+var CommitStyles = []string{"conventional", "simple", "detailed"}
+
+// Problem is a configuration value gommit cannot use; Field is its dotted path in the config file.
+// Line and Column locate it in the file when it was found by Check.
+type Problem struct {
+	Field   string
+	Message string
+	Line    int
+	Column  int
+	Warning bool // an unknown field: it is ignored, as it may come from a newer gommit or another tool
+}
+
+func (p Problem) Error() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("line %d, column %d: %s: %s", p.Line, p.Column, p.Field, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.Field, p.Message)
+}
+
+// Validate checks the configuration values that the schema cannot express: the default provider must
+// be configured, each provider needs the fields its metadata (llm.Providers) marks as required, and
+// each policy rule must name a known provider or an endpoint.
+// Problems are returned in a stable order.
+func Validate(cfg *types.Config) []Problem {
+	var problems []Problem
+	add := func(field, format string, args ...any) {
+		problems = append(problems, Problem{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if cfg.DefaultProvider == "" {
+		if _, ok := cfg.Providers[defaultProvider]; !ok {
+			add("default_provider", "is required (or configure the %s provider)", defaultProvider)
+		}
+	} else if _, ok := cfg.Providers[cfg.DefaultProvider]; !ok {
+		add("default_provider", "provider %q is not configured in providers", cfg.DefaultProvider)
+	}
+
+	names := make([]string, 0, len(cfg.Providers))
+	for name := range cfg.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pc := cfg.Providers[name]
+		field := "providers." + name
+		meta, ok := llm.ProviderByTitle(name)
+		if !ok {
+			// reported by the schema
+			continue
+		}
+		if slices.Contains(meta.Required, "api_key") && pc.APIKey == "" {
+			add(field+".api_key", "is required")
+		}
+		if slices.Contains(meta.Required, "uri") && pc.URI == "" {
+			add(field+".uri", "is required")
+		}
+		if _, err := llm.Endpoint(types.ProviderName(name), pc); err != nil {
+			add(field+".uri", "%v", err)
+		}
+	}
+
+	for i, policy := range cfg.Policies {
+		for _, kind := range []string{"allow", "deny"} {
+			rules := policy.Allow
+			if kind == "deny" {
+				rules = policy.Deny
+			}
+			for j, rule := range rules {
+				field := fmt.Sprintf("policies[%d].%s[%d]", i, kind, j)
+				if rule.Provider == "" && rule.Endpoint == "" {
+					add(field, "needs a provider or an endpoint")
+				} else if _, ok := llm.ProviderByTitle(rule.Provider); rule.Provider != "" && !ok {
+					add(field+".provider", "unknown provider %q", rule.Provider)
+				}
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,175 @@
+package enrich
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/edhuardotierrez/gommit/internal/git"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+const (
+	DefaultFileHistory = 3
+	DefaultMaxChars    = 2000
+)
+
+// Language finds the declaration (function, method, class or type) enclosing a line of a source file
+type Language interface {
+	// Name identifies the language in the `context.languages` setting
+	Name() string
+	// Match reports whether the language handles the file
+	Match(path string) bool
+	// Enclosing returns the signature of the declaration containing the 1-based line, or ""
+	Enclosing(src []byte, line int) string
+}
+
+// languages are consulted in order; the first match handles the file
+var languages []Language
+
+// Register adds a language; later registrations take precedence over earlier ones
+func Register(l Language) {
+	languages = append([]Language{l}, languages...)
+}
+
+// hunkHeader matches "@@ -a,b +c,d @@ optional function name"
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@ ?(.*)$`)
+
+// Hunk is the position of a change in the new version of a file
+type Hunk struct {
+	Line     int    // first changed line (1-based, new file numbering)
+	Function string // the function name git put in the hunk header, if any
+}
+
+// Hunks returns the first changed line of every hunk of a unified diff
+func Hunks(diff string) []Hunk {
+	var hunks []Hunk
+	line, pending := 0, false
+	for _, l := range strings.Split(diff, "\n") {
+		if m := hunkHeader.FindStringSubmatch(l); m != nil {
+			start, _ := strconv.Atoi(m[1])
+			hunks = append(hunks, Hunk{Line: start, Function: strings.TrimSpace(m[2])})
+			line, pending = start, true
+			continue
+		}
+		if !pending || strings.HasPrefix(l, "+++") || strings.HasPrefix(l, "---") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(l, "+"), strings.HasPrefix(l, "-"):
+			hunks[len(hunks)-1].Line = line
+			pending = false
+		case strings.HasPrefix(l, " "):
+			line++
+		}
+	}
+	return hunks
+}
+
+// Build returns the context section for the changed files: the declarations enclosing each hunk and
+// the previous commit subjects touching each file, trimmed to the configured character budget
+func Build(cfg types.ContextConfig, changes []git.StagedChange) string {
+	if !cfg.Enabled || len(changes) == 0 {
+		return ""
+	}
+	maxChars := cfg.MaxChars
+	if maxChars <= 0 {
+		maxChars = DefaultMaxChars
+	}
+	fileHistory := cfg.FileHistory
+	if fileHistory == 0 {
+		fileHistory = DefaultFileHistory
+	}
+
+	header := "Additional context (declarations around each change and earlier commits touching each file):\n"
+	var b strings.Builder
+	for _, c := range changes {
+		section := fileContext(cfg, c, fileHistory)
+		if section == "" {
+			continue
+		}
+		if len(header)+b.Len()+len(section) > maxChars {
+			break
+		}
+		b.WriteString(section)
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return header + b.String()
+}
+
+// fileContext describes one changed file
+func fileContext(cfg types.ContextConfig, change git.StagedChange, fileHistory int) string {
+	var declarations []string
+	if !strings.HasPrefix(change.Status, "D") {
+		declarations = Enclosing(cfg, change.Path, change.Diff)
+	}
+
+	var subjects []string
+	if fileHistory > 0 && !strings.HasPrefix(change.Status, "A") {
+		if commits, err := git.GetRecentCommits(fileHistory, []string{change.Path}); err == nil {
+			for _, c := range commits {
+				subject, _, _ := strings.Cut(c.Message, "\n")
+				subjects = append(subjects, strings.TrimSpace(subject))
+			}
+		}
+	}
+
+	if len(declarations) == 0 && len(subjects) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "File: %s\n", change.Path)
+	for _, d := range declarations {
+		fmt.Fprintf(&b, "  Changed in: %s\n", d)
+	}
+	for _, s := range subjects {
+		fmt.Fprintf(&b, "  Earlier commit: %s\n", s)
+	}
+	return b.String()
+}
+
+// Enclosing returns the distinct declarations enclosing the hunks of a file's diff. Files without a
+// registered language (or outside cfg.Languages), or whose content cannot be read, use the function
+// name from git's hunk header.
+func Enclosing(cfg types.ContextConfig, path, diff string) []string {
+	hunks := Hunks(diff)
+	if len(hunks) == 0 {
+		return nil
+	}
+
+	lang := languageFor(cfg, path)
+	var src []byte
+	if lang != nil {
+		src, _ = git.GetFileContent(path)
+	}
+
+	var found []string
+	for _, h := range hunks {
+		// A registered language is authoritative; git's hunk header is only a fallback
+		decl := h.Function
+		if lang != nil && src != nil {
+			decl = lang.Enclosing(src, h.Line)
+		}
+		if decl != "" && !slices.Contains(found, decl) {
+			found = append(found, decl)
+		}
+	}
+	return found
+}
+
+func languageFor(cfg types.ContextConfig, path string) Language {
+	for _, l := range languages {
+		if len(cfg.Languages) > 0 && !slices.Contains(cfg.Languages, l.Name()) {
+			continue
+		}
+		if l.Match(path) {
+			return l
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package enrich
+
+import (
+	"testing"
+)
+
+// TestHunks maps each hunk to its first changed line in the new file.
+func TestHunks(t *testing.T) {
+	diff := "diff --git a/x.go b/x.go\n--- a/x.go\n+++ b/x.go\n" +
+		"@@ -10,6 +10,6 @@ func load() error {\n context\n context\n-old\n+new\n" +
+		"@@ -40,3 +40,4 @@\n+added\n context\n"
+
+	hunks := Hunks(diff)
+	want := []Hunk{{Line: 12, Function: "func load() error {"}, {Line: 40}}
+	if len(hunks) != len(want) {
+		t.Fatalf("Hunks() = %+v, want %+v", hunks, want)
+	}
+	for i := range want {
+		if hunks[i] != want[i] {
+			t.Fatalf("Hunks()[%d] = %+v, want %+v", i, hunks[i], want[i])
+		}
+	}
+}
+
+// TestEnclosing checks the declaration found around a line for each built-in language.
+func TestEnclosing(t *testing.T) {
+	goSrc := `package cache
+
+// DefaultMaxAge is how long generated messages are reused
+const DefaultMaxAge = 7 * 24
+
+// Options configure the cache
+type Options struct {
+	TTL int
+}
+
+// Get returns the entry for key.
+// It ignores expired entries.
+func (c *Cache) Get(key string, maxAge int) (*Entry, bool) {
+	if maxAge == 0 {
+		return nil, false
+	}
+	return c.read(key)
+}
+`
+	pySrc := `class Loader:
+    def load(self, path):
+        if not path:
+            return None
+        return open(path)
+
+def helper():
+    pass
+`
+	tsSrc := `export class Api {
+  async fetchUser(id: string): Promise<User> {
+    if (!id) {
+      throw new Error("missing id");
+    }
+    return this.get(id);
+  }
+}
+
+export const retry = async (fn: () => void) => {
+  fn();
+};
+`
+
+	cases := []struct {
+		name string
+		lang Language
+		src  string
+		line int
+		want string
+	}{
+		{name: "go const", lang: goLanguage{}, src: goSrc, line: 4, want: "const DefaultMaxAge // DefaultMaxAge is how long generated messages are reused"},
+		{name: "go struct field", lang: goLanguage{}, src: goSrc, line: 8, want: "type Options struct // Options configure the cache"},
+		{name: "go method", lang: goLanguage{}, src: goSrc, line: 15, want: "func (c *Cache) Get(key string, maxAge int) (*Entry, bool) // Get returns the entry for key."},
+		{name: "python method", lang: languageNamed("python"), src: pySrc, line: 4, want: "def load(self, path):"},
+		{name: "python function", lang: languageNamed("python"), src: pySrc, line: 8, want: "def helper():"},
+		{name: "typescript method", lang: languageNamed("typescript"), src: tsSrc, line: 4, want: "async fetchUser(id: string): Promise<User>"},
+		{name: "typescript arrow", lang: languageNamed("typescript"), src: tsSrc, line: 11, want: "export const retry = async (fn: () => void) =>"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.lang.Enclosing([]byte(tc.src), tc.line); got != tc.want {
+				t.Fatalf("Enclosing(%d) = %q, want %q", tc.line, got, tc.want)
+			}
+		})
+	}
+}
+
+func languageNamed(name string) Language {
+	for _, l := range languages {
+		if l.Name() == name {
+			return l
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package enrich
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+)
+
+func init() {
+	Register(goLanguage{})
+}
+
+// goLanguage uses the standard Go parser
+type goLanguage struct{}
+
+func (goLanguage) Name() string { return "go" }
+
+func (goLanguage) Match(path string) bool { return strings.HasSuffix(path, ".go") }
+
+func (goLanguage) Enclosing(src []byte, line int) string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return ""
+	}
+
+	for _, decl := range file.Decls {
+		if fset.Position(decl.Pos()).Line > line || fset.Position(decl.End()).Line < line {
+			continue
+		}
+
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			signature := *d
+			signature.Body, signature.Doc = nil, nil
+			return withDoc(d.Doc, render(fset, &signature))
+
+		case *ast.GenDecl:
+			// Report the spec containing the line (a type, or a const/var of a block)
+			for _, spec := range d.Specs {
+				if fset.Position(spec.Pos()).Line > line || fset.Position(spec.End()).Line < line {
+					continue
+				}
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					return withDoc(d.Doc, "type "+s.Name.Name+" "+typeKind(s.Type))
+				case *ast.ValueSpec:
+					doc := s.Doc
+					if doc == nil {
+						doc = d.Doc
+					}
+					names := make([]string, len(s.Names))
+					for i, n := range s.Names {
+						names[i] = n.Name
+					}
+					return withDoc(doc, d.Tok.String()+" "+strings.Join(names, ", "))
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func render(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+func typeKind(expr ast.Expr) string {
+	switch expr.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	case *ast.FuncType:
+		return "func"
+	default:
+		return ""
+	}
+}
+
+// withDoc prefixes a signature with the first sentence of its doc comment, which often carries the "why"
+func withDoc(doc *ast.CommentGroup, signature string) string {
+	if doc == nil {
+		return strings.TrimSpace(signature)
+	}
+	text, _, _ := strings.Cut(strings.TrimSpace(doc.Text()), "\n")
+	if text == "" {
+		return strings.TrimSpace(signature)
+	}
+	return strings.TrimSpace(signature) + " // " + text
+}
@@ -0,0 +1,100 @@
+package enrich
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+func init() {
+	Register(scanLanguage{
+		name:        "python",
+		extensions:  []string{".py"},
+		declaration: regexp.MustCompile(`^\s*(?:async\s+)?(?:def|class)\s+\w+`),
+	})
+	Register(scanLanguage{
+		name:       "typescript",
+		extensions: []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs"},
+		declaration: regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?` +
+			`(?:function\*?\s+[\w$]+|class\s+[\w$]+|interface\s+[\w$]+|enum\s+[\w$]+|type\s+[\w$]+\s*=|` +
+			`(?:const|let|var)\s+[\w$]+\s*=\s*(?:async\s+)?(?:\([^)]*\)|[\w$]+)\s*(?::\s*[^=]+)?=>|` +
+			`(?:(?:public|private|protected|static|readonly|get|set)\s+)*[\w$]+\s*\([^)]*\)\s*(?::\s*[^{]+)?\{\s*$)`),
+	})
+}
+
+// scanLanguage is a lightweight, indentation-based finder: the enclosing declaration is the nearest
+// line above the change that matches the declaration pattern and is indented less than the change
+type scanLanguage struct {
+	name        string
+	extensions  []string
+	declaration *regexp.Regexp
+}
+
+// keywords that look like method calls to the TypeScript pattern
+var controlKeywords = []string{"if", "for", "while", "switch", "catch", "return", "function"}
+
+func (l scanLanguage) Name() string { return l.name }
+
+func (l scanLanguage) Match(path string) bool {
+	return slices.Contains(l.extensions, strings.ToLower(filepath.Ext(path)))
+}
+
+func (l scanLanguage) Enclosing(src []byte, line int) string {
+	lines := strings.Split(string(src), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	limit := indentation(lines[line-1])
+	if strings.TrimSpace(lines[line-1]) == "" {
+		limit = 1 << 30
+	}
+	// A change on the declaration line itself belongs to it
+	if l.isDeclaration(lines[line-1]) {
+		return strings.TrimSpace(lines[line-1])
+	}
+
+	for i := line - 2; i >= 0; i-- {
+		text := lines[i]
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		indent := indentation(text)
+		if indent >= limit {
+			continue
+		}
+		if l.isDeclaration(text) {
+			return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "{"))
+		}
+		limit = indent
+		if limit == 0 {
+			break
+		}
+	}
+	return ""
+}
+
+func (l scanLanguage) isDeclaration(text string) bool {
+	if !l.declaration.MatchString(text) {
+		return false
+	}
+	word := strings.FieldsFunc(strings.TrimSpace(text), func(r rune) bool { return r == ' ' || r == '(' })
+	return len(word) == 0 || !slices.Contains(controlKeywords, word[0]) || word[0] == "function"
+}
+
+// indentation counts leading whitespace, with tabs as four columns
+func indentation(text string) int {
+	n := 0
+	for _, r := range text {
+		switch r {
+		case ' ':
+			n++
+		case '\t':
+			n += 4
+		default:
+			return n
+		}
+	}
+	return n
+}
@@ -1,11 +1,12 @@
 package git
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -15,6 +16,45 @@ func IsGitRepository() bool {
 	return cmd.Run() == nil
 }
 
+// GetVersion returns the installed git version, e.g. "2.43.0"
+func GetVersion() (string, error) {
+	output, err := exec.Command("git", "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("error running git: %w", err)
+	}
+	// "git version 2.39.3 (Apple Git-145)"
+	fields := strings.Fields(string(output))
+	if len(fields) < 3 {
+		return "", fmt.Errorf("unexpected git version output %q", strings.TrimSpace(string(output)))
+	}
+	return fields[2], nil
+}
+
+// operationMarkers are the git directory entries present while an operation is stopped midway
+var operationMarkers = []struct{ path, name string }{
+	{"rebase-merge", "rebase"},
+	{"rebase-apply", "rebase"},
+	{"MERGE_HEAD", "merge"},
+	{"CHERRY_PICK_HEAD", "cherry-pick"},
+	{"REVERT_HEAD", "revert"},
+	{"BISECT_LOG", "bisect"},
+}
+
+// GetOperationInProgress returns the merge, rebase, cherry-pick, revert or bisect the repository is
+// in the middle of, or "" when there is none
+func GetOperationInProgress() string {
+	for _, m := range operationMarkers {
+		path, err := GetGitPath(m.path)
+		if err != nil {
+			return ""
+		}
+		if _, err := os.Stat(path); err == nil {
+			return m.name
+		}
+	}
+	return ""
+}
+
 // StagedChange represents a staged file change
 type StagedChange struct {
 	Path   string
@@ -22,8 +62,8 @@ type StagedChange struct {
 	Diff   string
 }
 
-// getTopLevelGitPath returns the absolute path of the git repository root
-func getTopLevelGitPath() string {
+// GetRootPath returns the absolute path of the git repository root
+func GetRootPath() string {
 	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
 	output, err := cmd.Output()
 	if err != nil {
@@ -32,9 +72,42 @@ func getTopLevelGitPath() string {
 	return strings.Trim(string(output), "\n")
 }
 
+// GetRemoteURL returns the URL of a remote as `git remote get-url` prints it, or "" when the
+// remote does not exist
+func GetRemoteURL(name string) string {
+	output, err := exec.Command("git", "remote", "get-url", name).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// emptyTree is the hash of git's empty tree, used as the base when the repository has no commits yet
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
 // GetStagedChanges returns a list of staged changes in the repository
 func GetStagedChanges() ([]StagedChange, error) {
-	cmd := exec.Command("git", "diff", "--cached", "--name-status")
+	return GetCommitChanges(false, nil)
+}
+
+// GetCommitChanges returns the changes a commit would record: the index by default, every tracked
+// modification when all is set (`git commit -a`), or the working tree state of the given paths
+// (`git commit -- <paths>`)
+func GetCommitChanges(all bool, paths []string) ([]StagedChange, error) {
+	base := []string{"--cached"}
+	if all || len(paths) > 0 {
+		head := "HEAD"
+		if exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD").Run() != nil {
+			head = emptyTree
+		}
+		base = []string{head}
+	}
+
+	args := append([]string{"diff", "--name-status"}, base...)
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	cmd := exec.Command("git", args...)
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("error getting staged files: %w", err)
@@ -52,24 +125,29 @@ func GetStagedChanges() ([]StagedChange, error) {
 		return nil, fmt.Errorf("error getting working directory: %w", err)
 	}
 
-	rootPath := getTopLevelGitPath()
+	rootPath := GetRootPath()
 	relativeRootPath := strings.TrimPrefix(workingDir, rootPath+"/")
 
 	for _, line := range lines {
-		parts := strings.Fields(line)
+		parts := strings.Split(line, "\t")
 		if len(parts) < 2 {
 			continue
 		}
 
 		status := parts[0]
-		path := parts[1]
-
-		relativePath := path
-		if workingDir != "" {
-			relativePath = strings.TrimPrefix(path, relativeRootPath+"/")
+		// Renames and copies list the old and the new path; both are diffed so git can pair them
+		var pathspec []string
+		for _, p := range parts[1:] {
+			if workingDir != "" {
+				p = strings.TrimPrefix(p, relativeRootPath+"/")
+			}
+			pathspec = append(pathspec, p)
 		}
+		path := parts[len(parts)-1]
 
-		cmd = exec.Command("git", "--no-pager", "diff", "--staged", "--cached", "--", relativePath)
+		diffArgs := append([]string{"--no-pager", "diff"}, base...)
+		diffArgs = append(append(diffArgs, "--"), pathspec...)
+		cmd = exec.Command("git", diffArgs...)
 		diff, err := cmd.Output()
 		if err != nil {
 			return nil, fmt.Errorf("error getting diff for %s: %w", path, err)
@@ -85,52 +163,144 @@ func GetStagedChanges() ([]StagedChange, error) {
 	return changes, nil
 }
 
-// Commit creates a new commit with the given message
-func Commit(message string) error {
-	cmd := exec.Command("git", "commit", "-m", message)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	cmd.Stdout = os.Stdout
+// CommitOptions controls how a commit is created
+type CommitOptions struct {
+	Message   string   // written to a temp file and passed with -F; empty lets git build it (e.g. --fixup)
+	Signoff   bool     // add a Signed-off-by trailer (--signoff)
+	GPGSign   bool     // GPG-sign the commit (-S)
+	All       bool     // commit every tracked modification (-a)
+	Paths     []string // commit only these paths, as `git commit -- <paths>`
+	ExtraArgs []string // forwarded verbatim to `git commit`
+}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("error creating commit: %w\n%s", err, stderr.String())
+// CommitError is returned when `git commit` fails, typically because a hook rejected the commit
+type CommitError struct {
+	Output string // combined stdout and stderr of git and its hooks, already shown to the user
+	Err    error
+}
+
+func (e *CommitError) Error() string {
+	return fmt.Sprintf("error creating commit: %v", e.Err)
+}
+
+func (e *CommitError) Unwrap() error {
+	return e.Err
+}
+
+// messageArgs are the `git commit` options that supply a message, which gommit owns
+var messageArgs = []string{"-m", "--message", "-F", "--file", "-C", "--reuse-message", "-c", "--reedit-message", "-t", "--template"}
+
+// ValidateCommitArgs rejects forwarded arguments that would replace the generated message
+func ValidateCommitArgs(args []string) error {
+	for _, a := range args {
+		name, _, _ := strings.Cut(a, "=")
+		for _, m := range messageArgs {
+			if name == m || (len(m) == 2 && strings.HasPrefix(a, m) && !strings.HasPrefix(a, "--")) {
+				return fmt.Errorf("%s cannot be forwarded: the commit message is provided by gommit", m)
+			}
+		}
 	}
+	return nil
+}
+
+// args returns the `git commit` arguments for the options and the message file
+func (o CommitOptions) args(messageFile string) []string {
+	args := []string{"commit"}
+	if messageFile != "" {
+		// Keep lines starting with '#' even when commit.cleanup is set to strip
+		args = append(args, "-F", messageFile, "--cleanup=whitespace")
+	}
+	if o.Signoff {
+		args = append(args, "--signoff")
+	}
+	if o.GPGSign {
+		args = append(args, "-S")
+	}
+	if o.All {
+		args = append(args, "-a")
+	}
+	args = append(args, o.ExtraArgs...)
+	if len(o.Paths) > 0 {
+		args = append(append(args, "--"), o.Paths...)
+	}
+	return args
+}
 
+// Commit creates a new commit with the given options. The message goes through a temp file so
+// multi-paragraph messages, lines starting with '#' and very long messages survive intact.
+// With a forwarded --edit, git opens the editor on the generated message.
+func Commit(opts CommitOptions) error {
+	messageFile := ""
+	if opts.Message != "" {
+		f, err := os.CreateTemp("", "gommit-msg-*.txt")
+		if err != nil {
+			return fmt.Errorf("error writing commit message: %w", err)
+		}
+		defer os.Remove(f.Name())
+
+		_, err = f.WriteString(opts.Message + "\n")
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("error writing commit message: %w", err)
+		}
+		messageFile = f.Name()
+	}
+
+	// git and its hooks talk to the terminal as usual (an --edit editor, pinentry, pre-commit
+	// progress); the output is also kept for the error
+	cmd := exec.Command("git", opts.args(messageFile)...)
+	var output bytes.Buffer
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = io.MultiWriter(os.Stdout, &output)
+	cmd.Stderr = io.MultiWriter(os.Stderr, &output)
+
+	if err := cmd.Run(); err != nil {
+		return &CommitError{Output: output.String(), Err: err}
+	}
 	return nil
 }
 
-// GetUnstagedChanges returns a list of modified but unstaged files
+// GetUnstagedChanges returns a list of modified but unstaged files, including untracked ones
 func GetUnstagedChanges() ([]StagedChange, error) {
-	cmd := exec.Command("git", "status", "--porcelain")
+	// -z keeps paths unquoted and lists renames as "XY new\0old\0"
+	cmd := exec.Command("git", "status", "--porcelain", "-z", "--untracked-files=all")
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("error getting unstaged changes: %w", err)
 	}
 
 	var changes []StagedChange
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	entries := strings.Split(string(output), "\x00")
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if len(line) < 4 {
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
 			continue
 		}
 
-		// Check for unstaged changes (M in second column)
-		statusCode := line[0:2]
-		if statusCode[1] == 'M' || statusCode[1] == '?' || statusCode[1] == 'D' {
-			path := strings.TrimSpace(line[3:])
-			status := getStatusDescription(statusCode[1])
-			changes = append(changes, StagedChange{
-				Path:   path,
-				Status: status,
-			})
+		statusCode := entry[0:2]
+		// The original path of a rename or copy follows as its own entry
+		if statusCode[0] == 'R' || statusCode[0] == 'C' || statusCode[1] == 'R' || statusCode[1] == 'C' {
+			i++
 		}
+
+		// Only changes in the working tree (second column) are unstaged
+		status := getStatusDescription(statusCode[1])
+		if status == "" {
+			continue
+		}
+		changes = append(changes, StagedChange{
+			Path:   entry[3:],
+			Status: status,
+		})
 	}
 
 	return changes, nil
 }
 
+// getStatusDescription describes a porcelain status code; "" for unchanged or unmerged entries
 func getStatusDescription(code byte) string {
 	switch code {
 	case 'M':
@@ -139,7 +309,207 @@ func getStatusDescription(code byte) string {
 		return "untracked"
 	case 'D':
 		return "deleted"
+	case 'A':
+		return "added"
+	case 'R':
+		return "renamed"
+	case 'C':
+		return "copied"
+	case 'T':
+		return "type changed"
 	default:
-		return "unknown"
+		return ""
+	}
+}
+
+// Stage adds the given paths (including deletions and untracked files) to the index
+func Stage(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	cmd := exec.Command("git", append([]string{"add", "--all", "--"}, paths...)...)
+	cmd.Dir = GetRootPath() // porcelain paths are relative to the repository root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error staging files: %w\n%s", err, stderr.String())
+	}
+	return nil
+}
+
+// CommitMessage is a commit hash with its full message
+type CommitMessage struct {
+	Hash    string
+	Message string
+}
+
+// GetCommitMessages returns the messages of the commits in a revision range (e.g. "main..HEAD"), newest first
+func GetCommitMessages(revisionRange string) ([]CommitMessage, error) {
+	cmd := exec.Command("git", "log", "--format=%H%x00%B%x1e", revisionRange, "--")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error reading commits in %s: %w\n%s", revisionRange, err, stderr.String())
+	}
+
+	var commits []CommitMessage
+	for _, record := range strings.Split(string(output), "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		hash, message, ok := strings.Cut(record, "\x00")
+		if !ok {
+			continue
+		}
+		commits = append(commits, CommitMessage{Hash: hash, Message: strings.TrimSpace(message)})
+	}
+
+	return commits, nil
+}
+
+// GetHooksPath returns the directory git runs hooks from (honouring core.hooksPath)
+func GetHooksPath() (string, error) {
+	path, err := GetGitPath("hooks")
+	if err != nil {
+		return "", fmt.Errorf("error getting hooks path: %w", err)
+	}
+	return path, nil
+}
+
+// GetGitPath returns the absolute path of a file or directory inside the repository's git directory
+// (e.g. "hooks" -> "/repo/.git/hooks"), honouring worktrees and core.hooksPath
+func GetGitPath(name string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", name)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error resolving git path %s: %w", name, err)
+	}
+
+	path := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(path) {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+	}
+	return path, nil
+}
+
+// GetFileContent returns the staged content of a repository-relative path, falling back to the
+// working tree when the path is not in the index
+func GetFileContent(path string) ([]byte, error) {
+	if output, err := exec.Command("git", "show", ":"+path).Output(); err == nil {
+		return output, nil
+	}
+	content, err := os.ReadFile(filepath.Join(GetRootPath(), filepath.FromSlash(path)))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return content, nil
+}
+
+// WriteTree writes the index as a tree object and returns its hash, identifying the staged content
+func WriteTree() (string, error) {
+	cmd := exec.Command("git", "write-tree")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error writing tree: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetCurrentBranch returns the short name of the checked-out branch. While a rebase is in progress
+// HEAD is detached, so the branch being rebased is read from the rebase state instead. It returns ""
+// for a detached HEAD outside a rebase.
+func GetCurrentBranch() string {
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	branch := strings.TrimSpace(string(output))
+	if branch != "HEAD" {
+		return branch
+	}
+
+	for _, state := range []string{"rebase-merge/head-name", "rebase-apply/head-name"} {
+		pathOutput, err := exec.Command("git", "rev-parse", "--git-path", state).Output()
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(strings.TrimSpace(string(pathOutput)))
+		if err != nil {
+			continue
+		}
+		return strings.TrimPrefix(strings.TrimSpace(string(data)), "refs/heads/")
+	}
+
+	return ""
+}
+
+// CommitInfo is a commit with its author and full message
+type CommitInfo struct {
+	Hash        string
+	AuthorName  string
+	AuthorEmail string
+	Message     string
+}
+
+// GetRecentCommits returns up to limit non-merge commits reachable from HEAD, newest first,
+// optionally restricted to commits touching the given repository paths
+func GetRecentCommits(limit int, paths []string) ([]CommitInfo, error) {
+	args := []string{"log", "--no-merges", fmt.Sprintf("--max-count=%d", limit), "--format=%H%x00%an%x00%ae%x00%B%x1e", "HEAD", "--"}
+	cmd := exec.Command("git", args...)
+	if len(paths) > 0 {
+		// Paths from `git diff --name-status` are relative to the repository root
+		cmd = exec.Command("git", append(args, paths...)...)
+		cmd.Dir = GetRootPath()
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error reading commit history: %w", err)
+	}
+
+	var commits []CommitInfo
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		commits = append(commits, CommitInfo{
+			Hash:        fields[0],
+			AuthorName:  fields[1],
+			AuthorEmail: fields[2],
+			Message:     strings.TrimSpace(fields[3]),
+		})
+	}
+
+	return commits, nil
+}
+
+// GetRecentAuthors returns the distinct "Name <email>" authors of the last limit commits, most
+// recent first, excluding the configured user
+func GetRecentAuthors(limit int) ([]string, error) {
+	cmd := exec.Command("git", "log", "--no-merges", fmt.Sprintf("--max-count=%d", limit), "--format=%an <%ae>")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error reading commit authors: %w", err)
+	}
+
+	self := ""
+	if email, err := exec.Command("git", "config", "user.email").Output(); err == nil && strings.TrimSpace(string(email)) != "" {
+		self = "<" + strings.TrimSpace(string(email)) + ">"
+	}
+
+	seen := map[string]bool{}
+	var authors []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || seen[line] || (self != "" && strings.HasSuffix(line, self)) {
+			continue
+		}
+		seen[line] = true
+		authors = append(authors, line)
 	}
+	return authors, nil
 }
@@ -0,0 +1,152 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCommit_MessageFileAndHooks checks that messages survive verbatim, extra args are forwarded
+// and a failing pre-commit hook surfaces its output.
+func TestCommit_MessageFileAndHooks(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+	run("init", "-q")
+	run("config", "user.name", "Jane")
+	run("config", "user.email", "jane@example.com")
+	run("config", "commit.cleanup", "strip")
+
+	message := "feat: add parser\n\n# Heading kept verbatim\n\nSecond paragraph."
+	if err := Commit(CommitOptions{Message: message, ExtraArgs: []string{"--allow-empty", "--author", "Bob <bob@example.com>"}}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if got := strings.TrimSpace(run("log", "-1", "--format=%B")); got != message {
+		t.Fatalf("message = %q, want %q", got, message)
+	}
+	if got := strings.TrimSpace(run("log", "-1", "--format=%an")); got != "Bob" {
+		t.Fatalf("author = %q, want Bob", got)
+	}
+
+	hook := filepath.Join(dir, ".git", "hooks", "pre-commit")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\necho 'lint: trailing whitespace'\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Commit(CommitOptions{Message: "fix: something", ExtraArgs: []string{"--allow-empty"}})
+	var commitErr *CommitError
+	if !errors.As(err, &commitErr) {
+		t.Fatalf("expected CommitError, got %v", err)
+	}
+	if !strings.Contains(commitErr.Output, "lint: trailing whitespace") {
+		t.Fatalf("hook output missing: %q", commitErr.Output)
+	}
+
+	if err := Commit(CommitOptions{Message: "fix: something", ExtraArgs: []string{"--allow-empty", "--no-verify"}}); err != nil {
+		t.Fatalf("Commit with --no-verify failed: %v", err)
+	}
+}
+
+// TestValidateCommitArgs rejects options that would replace the generated message.
+func TestValidateCommitArgs(t *testing.T) {
+	for _, args := range [][]string{{"-m", "x"}, {"-mx"}, {"--message=x"}, {"--file", "f"}, {"-C", "HEAD"}} {
+		if ValidateCommitArgs(args) == nil {
+			t.Errorf("ValidateCommitArgs(%q) should fail", args)
+		}
+	}
+	for _, args := range [][]string{{"--no-verify", "--author=Bob <bob@example.com>"}, {"--cleanup=verbatim", "--date", "now"}, {"--fixup=HEAD"}} {
+		if err := ValidateCommitArgs(args); err != nil {
+			t.Errorf("ValidateCommitArgs(%q) = %v", args, err)
+		}
+	}
+}
+
+// TestGetUnstagedChanges_PorcelainCodes covers modified, deleted, type-changed, intent-to-add and untracked entries.
+func TestGetUnstagedChanges_PorcelainCodes(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	run := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q")
+	for _, name := range []string{"modified.txt", "deleted.txt", "link.txt", "renamed.txt"} {
+		write(name, name+"\n")
+	}
+	run("add", ".")
+	run("-c", "user.name=Jane", "-c", "user.email=jane@example.com", "commit", "-q", "-m", "init")
+
+	write("modified.txt", "changed\n")
+	_ = os.Remove(filepath.Join(dir, "deleted.txt"))
+	_ = os.Remove(filepath.Join(dir, "link.txt"))
+	if err := os.Symlink("modified.txt", filepath.Join(dir, "link.txt")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	write("intent.txt", "new\n")
+	run("add", "-N", "intent.txt")
+	write("untracked file.txt", "new\n")
+	run("mv", "renamed.txt", "moved.txt")
+
+	changes, err := GetUnstagedChanges()
+	if err != nil {
+		t.Fatalf("GetUnstagedChanges failed: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, c := range changes {
+		got[c.Path] = c.Status
+	}
+	want := map[string]string{
+		"modified.txt":       "modified",
+		"deleted.txt":        "deleted",
+		"link.txt":           "type changed",
+		"intent.txt":         "added",
+		"untracked file.txt": "untracked",
+	}
+	for path, status := range want {
+		if got[path] != status {
+			t.Errorf("status of %q = %q, want %q (all: %v)", path, got[path], status, got)
+		}
+	}
+	// A staged rename has no working tree change
+	if _, ok := got["moved.txt"]; ok {
+		t.Errorf("staged rename reported as unstaged: %v", got)
+	}
+
+	all, err := GetCommitChanges(true, nil)
+	if err != nil {
+		t.Fatalf("GetCommitChanges failed: %v", err)
+	}
+	if len(all) == 0 {
+		t.Fatalf("GetCommitChanges(all) returned no changes")
+	}
+
+	only, err := GetCommitChanges(false, []string{"modified.txt"})
+	if err != nil {
+		t.Fatalf("GetCommitChanges failed: %v", err)
+	}
+	if len(only) != 1 || only[0].Path != "modified.txt" || !strings.Contains(only[0].Diff, "+changed") {
+		t.Fatalf("GetCommitChanges(paths) = %+v", only)
+	}
+}
@@ -1,3 +1,6 @@
 package globals
 
 var VerboseMode bool
+
+// RefreshCache skips cached commit messages and generates a new one
+var RefreshCache bool
@@ -0,0 +1,147 @@
+package history
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/edhuardotierrez/gommit/internal/git"
+	"github.com/edhuardotierrez/gommit/internal/lint"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// Default values for history sampling
+const (
+	DefaultCommits  = 50
+	DefaultExamples = 5
+	DefaultMaxChars = 2000
+
+	maxBodyLines = 6
+)
+
+// botPatterns match the names or emails of automated committers
+var botPatterns = []string{`\[bot\]`, `(?i)dependabot`, `(?i)renovate`, `(?i)github-actions`, `(?i)^bot@`, `(?i)semantic-release`}
+
+// withDefaults fills in unset limits
+func withDefaults(cfg types.HistoryConfig) types.HistoryConfig {
+	if cfg.Commits <= 0 {
+		cfg.Commits = DefaultCommits
+	}
+	if cfg.Examples <= 0 {
+		cfg.Examples = DefaultExamples
+	}
+	if cfg.MaxChars <= 0 {
+		cfg.MaxChars = DefaultMaxChars
+	}
+	return cfg
+}
+
+// Examples samples recent human-written commit messages, optionally limited to commits touching paths
+func Examples(cfg types.HistoryConfig, paths []string) ([]string, error) {
+	cfg = withDefaults(cfg)
+
+	var bots []*regexp.Regexp
+	for _, p := range append(botPatterns, cfg.ExcludeAuthors...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude_authors pattern %q: %w", p, err)
+		}
+		bots = append(bots, re)
+	}
+
+	if !cfg.TouchedPathsOnly {
+		paths = nil
+	}
+	commits, err := git.GetRecentCommits(cfg.Commits, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var candidates []string
+	for _, c := range commits {
+		if isBot(c, bots) || lint.Ignored(c.Message) {
+			continue
+		}
+		subject, body, _ := strings.Cut(c.Message, "\n")
+		subject = strings.TrimSpace(subject)
+		if len(subject) < 10 || seen[subject] {
+			continue
+		}
+		seen[subject] = true
+
+		message := subject
+		if cfg.IncludeBodies {
+			if body = trimBody(body); body != "" {
+				message += "\n\n" + body
+			}
+		}
+		candidates = append(candidates, message)
+	}
+
+	return spread(candidates, cfg.Examples), nil
+}
+
+// Prompt formats the examples as a few-shot section capped at maxChars
+func Prompt(examples []string, maxChars int) string {
+	if len(examples) == 0 {
+		return ""
+	}
+	if maxChars <= 0 {
+		maxChars = DefaultMaxChars
+	}
+
+	var b strings.Builder
+	b.WriteString("Recent commit messages from this repository. Match their tone, casing and prefix conventions (do not copy their content):\n")
+	added := 0
+	for _, e := range examples {
+		entry := "---\n" + e + "\n"
+		if b.Len()+len(entry) > maxChars {
+			break
+		}
+		b.WriteString(entry)
+		added++
+	}
+	if added == 0 {
+		return ""
+	}
+	b.WriteString("---\n")
+	return b.String()
+}
+
+func isBot(c git.CommitInfo, bots []*regexp.Regexp) bool {
+	for _, re := range bots {
+		if re.MatchString(c.AuthorName) || re.MatchString(c.AuthorEmail) {
+			return true
+		}
+	}
+	return false
+}
+
+// trimBody keeps the first lines of a body, without trailers
+func trimBody(body string) string {
+	var lines []string
+	for _, l := range strings.Split(strings.TrimSpace(body), "\n") {
+		if strings.HasPrefix(l, "Signed-off-by:") || strings.HasPrefix(l, "Co-authored-by:") {
+			continue
+		}
+		lines = append(lines, l)
+		if len(lines) == maxBodyLines {
+			break
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// spread picks n items evenly across the list so the examples span the sampled history
+func spread(items []string, n int) []string {
+	if len(items) <= n {
+		return items
+	}
+	out := make([]string, 0, n)
+	step := float64(len(items)) / float64(n)
+	for i := 0; i < n; i++ {
+		out = append(out, items[int(float64(i)*step)])
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package history
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// TestExamples_SkipsBotsAndMerges builds a small repository and checks which commits are sampled.
+func TestExamples_SkipsBotsAndMerges(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	run := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(author, message string) {
+		run("-c", "user.name="+author, "-c", "user.email="+strings.ToLower(author)+"@example.com", "commit", "--allow-empty", "-q", "-m", message)
+	}
+
+	run("init", "-q")
+	commit("Jane", "feat(api): add pagination to list endpoints")
+	commit("dependabot[bot]", "chore(deps): bump golang.org/x/net from 0.1 to 0.2")
+	commit("Jane", "Revert \"feat(api): add pagination to list endpoints\"")
+	commit("John", "fix(cli): handle empty config file\n\nThe loader crashed on empty files.\n\nSigned-off-by: John <john@example.com>")
+
+	examples, err := Examples(types.HistoryConfig{Enabled: true, IncludeBodies: true}, nil)
+	if err != nil {
+		t.Fatalf("Examples failed: %v", err)
+	}
+
+	want := []string{
+		"fix(cli): handle empty config file\n\nThe loader crashed on empty files.",
+		"feat(api): add pagination to list endpoints",
+	}
+	if len(examples) != len(want) {
+		t.Fatalf("Examples() = %q, want %q", examples, want)
+	}
+	for i := range want {
+		if examples[i] != want[i] {
+			t.Fatalf("Examples()[%d] = %q, want %q", i, examples[i], want[i])
+		}
+	}
+
+	if p := Prompt(examples, 60); strings.Contains(p, "fix(cli)") {
+		t.Fatalf("Prompt ignored the character budget: %q", p)
+	}
+}
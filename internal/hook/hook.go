@@ -0,0 +1,132 @@
+package hook
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/edhuardotierrez/gommit/internal/git"
+)
+
+// CommitMsg is the git hook that validates commit messages with `gommit lint`
+const CommitMsg = "commit-msg"
+
+// marker identifies hook scripts written by gommit so they can be updated or removed safely
+const marker = "# installed by gommit"
+
+// Status describes the installation state of a hook
+type Status string
+
+const (
+	StatusMissing   Status = "not installed"
+	StatusInstalled Status = "installed"
+	StatusForeign   Status = "other hook present"
+)
+
+// scripts maps hook names to the gommit arguments they run; "$1" is the hook's first argument
+var scripts = map[string]string{
+	CommitMsg: `lint "$1"`,
+}
+
+// Path returns the absolute path of the named hook in the current repository
+func Path(name string) (string, error) {
+	dir, err := git.GetHooksPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// GetStatus reports whether the named hook is installed by gommit, by something else, or missing
+func GetStatus(name string) (Status, string, error) {
+	path, err := Path(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return StatusMissing, path, nil
+	}
+	if err != nil {
+		return "", path, fmt.Errorf("could not read hook %s: %w", path, err)
+	}
+
+	if strings.Contains(string(data), marker) {
+		return StatusInstalled, path, nil
+	}
+	return StatusForeign, path, nil
+}
+
+// Install writes the named hook. An existing hook not written by gommit is only replaced
+// when force is set, and is kept next to it with a .bak suffix.
+func Install(name string, force bool) (string, error) {
+	args, ok := scripts[name]
+	if !ok {
+		return "", fmt.Errorf("unsupported hook: %s", name)
+	}
+
+	status, path, err := GetStatus(name)
+	if err != nil {
+		return "", err
+	}
+
+	if status == StatusForeign {
+		if !force {
+			return path, fmt.Errorf("a %s hook already exists at %s (use --force to replace it)", name, path)
+		}
+		if err := os.Rename(path, path+".bak"); err != nil {
+			return path, fmt.Errorf("could not back up existing hook: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return path, fmt.Errorf("could not create hooks directory: %w", err)
+	}
+
+	script := fmt.Sprintf("#!/bin/sh\n%s\n# Remove this file (or run `gommit hook uninstall`) to disable it.\nexec %s %s\n", marker, binary(), args)
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		return path, fmt.Errorf("could not write hook: %w", err)
+	}
+
+	return path, nil
+}
+
+// Uninstall removes the named hook if it was installed by gommit
+func Uninstall(name string) (string, error) {
+	status, path, err := GetStatus(name)
+	if err != nil {
+		return "", err
+	}
+
+	switch status {
+	case StatusMissing:
+		return path, nil
+	case StatusForeign:
+		return path, fmt.Errorf("the %s hook at %s was not installed by gommit", name, path)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return path, fmt.Errorf("could not remove hook: %w", err)
+	}
+	return path, nil
+}
+
+// binary returns how the hook should invoke gommit: by name when it is on PATH, otherwise by absolute path
+func binary() string {
+	if _, err := exec.LookPath("gommit"); err == nil {
+		return "gommit"
+	}
+	if exe, err := os.Executable(); err == nil {
+		return shellQuote(exe)
+	}
+	return "gommit"
+}
+
+// shellQuote quotes s for /bin/sh: inside single quotes nothing is expanded, and each single quote
+// in s closes the quotes, is escaped, and reopens them
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
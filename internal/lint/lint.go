@@ -0,0 +1,597 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/edhuardotierrez/gommit/internal/trailer"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// Rule names, usable in the `lint.disable` config list
+const (
+	RuleNoMarkdown       = "no-markdown"
+	RuleForbiddenPhrase  = "forbidden-phrase"
+	RuleSubjectEmpty     = "subject-empty"
+	RuleSubjectMaxLength = "subject-max-length"
+	RuleSubjectPeriod    = "subject-trailing-period"
+	RuleBodyLeadingBlank = "body-leading-blank"
+	RuleBodyMaxLineWidth = "body-max-line-width"
+	RuleMessageMaxLength = "message-max-length"
+	RuleTypeFormat       = "type-format"
+	RuleTypeEnum         = "type-enum"
+	RuleScopeEnum        = "scope-enum"
+	RuleScopeMatch       = "scope-match"
+	RuleSubjectTemplate  = "subject-template"
+	RuleTicketReference  = "ticket-reference"
+)
+
+// Default values for lint options
+const (
+	DefaultSubjectMaxLength = 72
+	DefaultBodyMaxLineWidth = 72
+	DefaultMaxRetries       = 1
+)
+
+// Ticket placements
+const (
+	TicketPrefix  = "prefix"
+	TicketFooter  = "footer"
+	TicketTrailer = "trailer"
+
+	// DefaultTicketPattern matches Jira-style keys such as PROJ-1234
+	DefaultTicketPattern = `[A-Z][A-Z0-9]+-[0-9]+`
+	DefaultTicketKey     = "Refs"
+)
+
+// DefaultTypes are the conventional-commit types accepted when none are configured
+var DefaultTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// DefaultForbiddenPhrases catch the chatty preambles models like to add around the message.
+// A leading "^" only matches the phrase at the start of a line.
+var DefaultForbiddenPhrases = []string{
+	"here's a commit message",
+	"here is a commit message",
+	"here's the commit message",
+	"here is the commit message",
+	"here's your commit message",
+	"^commit message:",
+	"as an ai",
+	"let me know if",
+}
+
+var (
+	headerPattern  = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()]*)\))?(!)?:\s*(.*)$`)
+	trailerPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*: \S`)
+)
+
+// Options configures which checks run and their limits
+type Options struct {
+	Style            string
+	SubjectMaxLength int
+	BodyMaxLineWidth int
+	MessageMaxLength int  // only for generated messages; 0 for hand-written ones
+	Generated        bool // the message comes from a model: markdown is reported, as it never belongs there
+	Types            []string
+	Scopes           []string
+	RequireScope     bool
+	ForbiddenPhrases []string
+	Disabled         []string
+	SubjectTemplate  string         // human-readable template, e.g. "{type}({scope}): {description}"
+	SubjectPattern   *regexp.Regexp // compiled form of SubjectTemplate
+	TicketPattern    *regexp.Regexp // ticket reference format, e.g. [A-Z]+-[0-9]+
+	TicketRequired   bool
+	InferredScope    string // scope inferred from the changed paths; the subject must use it
+	Ticket           string // ticket extracted from the branch; the message must reference it
+	TicketPlacement  string // where a missing Ticket is inserted: prefix, footer or trailer
+	TicketKey        string // key of the footer/trailer line, e.g. "Refs"
+}
+
+// Violation describes a single rule failure
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Fixable bool   `json:"fixable"`
+}
+
+func (v Violation) String() string {
+	if v.Line > 0 {
+		return fmt.Sprintf("line %d: %s (%s)", v.Line, v.Message, v.Rule)
+	}
+	return fmt.Sprintf("%s (%s)", v.Message, v.Rule)
+}
+
+// Header is the parsed form of a conventional-commit subject: type(scope)!: description
+type Header struct {
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+}
+
+// String renders the header back into a subject line
+func (h Header) String() string {
+	var b strings.Builder
+	b.WriteString(h.Type)
+	if h.Scope != "" {
+		fmt.Fprintf(&b, "(%s)", h.Scope)
+	}
+	if h.Breaking {
+		b.WriteString("!")
+	}
+	b.WriteString(": ")
+	b.WriteString(h.Description)
+	return b.String()
+}
+
+// ParseHeader parses a conventional-commit subject line
+func ParseHeader(subject string) (Header, bool) {
+	m := headerPattern.FindStringSubmatch(strings.TrimSpace(subject))
+	if m == nil {
+		return Header{}, false
+	}
+	return Header{Type: m[1], Scope: m[2], Breaking: m[3] == "!", Description: strings.TrimSpace(m[4])}, true
+}
+
+// OptionsFromConfig builds lint options from the application config and the effective commit style.
+// messageLimit is the length budget of a generated message; 0 lints a hand-written message, which
+// skips the checks that only catch model mistakes (the budget and markdown).
+func OptionsFromConfig(cfg *types.Config, style string, messageLimit int) Options {
+	opts := Options{
+		Style:            style,
+		SubjectMaxLength: cfg.Lint.SubjectMaxLength,
+		BodyMaxLineWidth: cfg.Lint.BodyMaxLineWidth,
+		MessageMaxLength: messageLimit,
+		Generated:        messageLimit > 0,
+		Types:            cfg.Lint.Types,
+		Scopes:           cfg.Lint.Scopes,
+		RequireScope:     cfg.Lint.RequireScope,
+		ForbiddenPhrases: append(slices.Clone(DefaultForbiddenPhrases), cfg.Lint.ForbiddenPhrases...),
+		Disabled:         cfg.Lint.Disable,
+		TicketPlacement:  cfg.Ticket.Placement,
+		TicketKey:        cfg.Ticket.TrailerKey,
+	}
+	if cfg.Ticket.Required {
+		opts.TicketRequired = true
+		opts.TicketPattern = regexp.MustCompile(DefaultTicketPattern)
+	}
+	if opts.TicketPlacement == "" {
+		opts.TicketPlacement = TicketFooter
+	}
+	if opts.TicketKey == "" {
+		opts.TicketKey = DefaultTicketKey
+	}
+	if opts.SubjectMaxLength == 0 {
+		opts.SubjectMaxLength = DefaultSubjectMaxLength
+	}
+	if opts.BodyMaxLineWidth == 0 {
+		opts.BodyMaxLineWidth = DefaultBodyMaxLineWidth
+	}
+	if len(opts.Types) == 0 {
+		opts.Types = DefaultTypes
+	}
+	return opts
+}
+
+// Enabled reports whether linting is enabled at all
+func (o Options) Enabled() bool {
+	return !slices.Contains(o.Disabled, "all")
+}
+
+// Check validates the message and returns all violations
+func Check(message string, opts Options) []Violation {
+	if !opts.Enabled() {
+		return nil
+	}
+
+	lines := splitLines(message)
+	var violations []Violation
+	for _, r := range rules {
+		if slices.Contains(opts.Disabled, r.name) {
+			continue
+		}
+		found := r.check(lines, opts)
+		if len(found) == 0 {
+			continue
+		}
+		// A violation is only fixable if applying the repair actually clears the rule
+		fixable := r.fix != nil && len(r.check(r.fix(slices.Clone(lines), opts), opts)) == 0
+		for _, v := range found {
+			v.Rule = r.name
+			v.Fixable = fixable
+			violations = append(violations, v)
+		}
+	}
+	return violations
+}
+
+// Fix applies every automatic repair it can and returns the repaired message with the remaining violations
+func Fix(message string, opts Options) (string, []Violation) {
+	if !opts.Enabled() {
+		return message, nil
+	}
+
+	lines := splitLines(message)
+	for _, r := range rules {
+		if r.fix == nil || slices.Contains(opts.Disabled, r.name) {
+			continue
+		}
+		if len(r.check(lines, opts)) > 0 {
+			lines = trimBlankLines(r.fix(slices.Clone(lines), opts))
+		}
+	}
+
+	fixed := strings.Join(lines, "\n")
+	return fixed, Check(fixed, opts)
+}
+
+// Ignored reports whether a message is generated by git itself (merges, reverts, fixups) and should not be linted
+func Ignored(message string) bool {
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	for _, prefix := range []string{"Merge ", "Revert \"", "fixup! ", "squash! ", "amend! "} {
+		if strings.HasPrefix(subject, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// StripComments removes the comment lines and the scissors section git adds to a message file
+func StripComments(message string) string {
+	var out []string
+	for _, line := range strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "# ------------------------ >8 ------------------------") {
+			break
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.Join(trimBlankLines(out), "\n")
+}
+
+// FormatViolations renders violations as a bullet list, used for re-prompting and previews
+func FormatViolations(violations []Violation) string {
+	var b strings.Builder
+	for _, v := range violations {
+		fmt.Fprintf(&b, "- %s\n", v)
+	}
+	return b.String()
+}
+
+type rule struct {
+	name  string
+	check func(lines []string, opts Options) []Violation
+	fix   func(lines []string, opts Options) []string
+}
+
+// rules are applied in order; fixes that strip noise run before the structural ones
+var rules = []rule{
+	{name: RuleNoMarkdown, check: checkMarkdown, fix: fixMarkdown},
+	{name: RuleForbiddenPhrase, check: checkForbidden, fix: fixForbidden},
+	{name: RuleSubjectEmpty, check: checkSubjectEmpty},
+	{name: RuleTypeFormat, check: checkTypeFormat, fix: fixTypeFormat},
+	{name: RuleTypeEnum, check: checkTypeEnum},
+	{name: RuleScopeMatch, check: checkScopeMatch, fix: fixScopeMatch},
+	{name: RuleScopeEnum, check: checkScopeEnum},
+	{name: RuleSubjectTemplate, check: checkSubjectTemplate},
+	{name: RuleTicketReference, check: checkTicketReference, fix: fixTicketReference},
+	{name: RuleSubjectPeriod, check: checkSubjectPeriod, fix: fixSubjectPeriod},
+	{name: RuleSubjectMaxLength, check: checkSubjectMaxLength},
+	{name: RuleBodyLeadingBlank, check: checkBodyLeadingBlank, fix: fixBodyLeadingBlank},
+	{name: RuleBodyMaxLineWidth, check: checkBodyMaxLineWidth, fix: fixBodyMaxLineWidth},
+	{name: RuleMessageMaxLength, check: checkMessageMaxLength},
+}
+
+func checkMarkdown(lines []string, opts Options) []Violation {
+	if !opts.Generated {
+		return nil
+	}
+	var out []Violation
+	for i, l := range lines {
+		switch {
+		case strings.HasPrefix(strings.TrimSpace(l), "```"):
+			out = append(out, Violation{Line: i + 1, Message: "markdown code fence"})
+		case strings.Contains(l, "`"):
+			out = append(out, Violation{Line: i + 1, Message: "backticks"})
+		}
+	}
+	return out
+}
+
+func fixMarkdown(lines []string, _ Options) []string {
+	out := make([]string, 0, len(lines))
+	for _, l := range lines {
+		if strings.HasPrefix(strings.TrimSpace(l), "```") {
+			continue
+		}
+		out = append(out, strings.ReplaceAll(l, "`", ""))
+	}
+	return out
+}
+
+func checkForbidden(lines []string, opts Options) []Violation {
+	var out []Violation
+	for i, l := range lines {
+		lower := strings.ToLower(strings.TrimSpace(l))
+		for _, p := range opts.ForbiddenPhrases {
+			phrase, anchored := strings.CutPrefix(strings.ToLower(p), "^")
+			if phrase == "" {
+				continue
+			}
+			if anchored && strings.HasPrefix(lower, phrase) || !anchored && strings.Contains(lower, phrase) {
+				out = append(out, Violation{Line: i + 1, Message: fmt.Sprintf("forbidden phrase %q", phrase)})
+				break
+			}
+		}
+	}
+	return out
+}
+
+// fixForbidden drops chatty lines from the start and end of the message; phrases in the middle need a re-prompt
+func fixForbidden(lines []string, opts Options) []string {
+	hasPhrase := func(l string) bool { return len(checkForbidden([]string{l}, opts)) > 0 }
+	for len(lines) > 0 && (hasPhrase(lines[0]) || strings.TrimSpace(lines[0]) == "") {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && (hasPhrase(lines[len(lines)-1]) || strings.TrimSpace(lines[len(lines)-1]) == "") {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func checkSubjectEmpty(lines []string, _ Options) []Violation {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) == "" {
+		return []Violation{{Line: 1, Message: "subject is empty"}}
+	}
+	return nil
+}
+
+func conventional(opts Options) bool {
+	return opts.Style == "conventional"
+}
+
+func checkTypeFormat(lines []string, opts Options) []Violation {
+	if !conventional(opts) || len(lines) == 0 {
+		return nil
+	}
+	h, ok := ParseHeader(lines[0])
+	if !ok || h.Description == "" {
+		return []Violation{{Line: 1, Message: "subject must match 'type(scope): description'"}}
+	}
+	if h.Type != strings.ToLower(h.Type) || lines[0] != h.String() {
+		return []Violation{{Line: 1, Message: "subject is not in canonical 'type(scope): description' form"}}
+	}
+	return nil
+}
+
+func fixTypeFormat(lines []string, _ Options) []string {
+	h, ok := ParseHeader(lines[0])
+	if !ok || h.Description == "" {
+		return lines
+	}
+	h.Type = strings.ToLower(h.Type)
+	h.Scope = strings.TrimSpace(h.Scope)
+	lines[0] = h.String()
+	return lines
+}
+
+func checkTypeEnum(lines []string, opts Options) []Violation {
+	if !conventional(opts) || len(lines) == 0 {
+		return nil
+	}
+	h, ok := ParseHeader(lines[0])
+	if !ok {
+		return nil
+	}
+	if !slices.Contains(opts.Types, strings.ToLower(h.Type)) {
+		return []Violation{{Line: 1, Message: fmt.Sprintf("type %q is not one of: %s", h.Type, strings.Join(opts.Types, ", "))}}
+	}
+	return nil
+}
+
+func checkScopeMatch(lines []string, opts Options) []Violation {
+	if !conventional(opts) || opts.InferredScope == "" || len(lines) == 0 {
+		return nil
+	}
+	h, ok := ParseHeader(lines[0])
+	if !ok || h.Scope == opts.InferredScope {
+		return nil
+	}
+	return []Violation{{Line: 1, Message: fmt.Sprintf("scope must be %q (inferred from the changed paths)", opts.InferredScope)}}
+}
+
+func fixScopeMatch(lines []string, opts Options) []string {
+	h, ok := ParseHeader(lines[0])
+	if !ok {
+		return lines
+	}
+	h.Scope = opts.InferredScope
+	lines[0] = h.String()
+	return lines
+}
+
+func checkScopeEnum(lines []string, opts Options) []Violation {
+	if !conventional(opts) || len(lines) == 0 {
+		return nil
+	}
+	h, ok := ParseHeader(lines[0])
+	if !ok {
+		return nil
+	}
+	if h.Scope == "" {
+		if opts.RequireScope {
+			return []Violation{{Line: 1, Message: "scope is required"}}
+		}
+		return nil
+	}
+	if len(opts.Scopes) > 0 && !slices.Contains(opts.Scopes, h.Scope) {
+		return []Violation{{Line: 1, Message: fmt.Sprintf("scope %q is not one of: %s", h.Scope, strings.Join(opts.Scopes, ", "))}}
+	}
+	return nil
+}
+
+func checkSubjectTemplate(lines []string, opts Options) []Violation {
+	if opts.SubjectPattern == nil || len(lines) == 0 {
+		return nil
+	}
+	if !opts.SubjectPattern.MatchString(lines[0]) {
+		return []Violation{{Line: 1, Message: fmt.Sprintf("subject must follow the template %q", opts.SubjectTemplate)}}
+	}
+	return nil
+}
+
+func checkTicketReference(lines []string, opts Options) []Violation {
+	message := strings.Join(lines, "\n")
+	if opts.Ticket != "" {
+		if !strings.Contains(message, opts.Ticket) {
+			return []Violation{{Message: fmt.Sprintf("message must reference ticket %s", opts.Ticket)}}
+		}
+		return nil
+	}
+	if opts.TicketRequired && opts.TicketPattern != nil && !opts.TicketPattern.MatchString(message) {
+		return []Violation{{Message: fmt.Sprintf("message must reference a ticket matching %s", opts.TicketPattern)}}
+	}
+	return nil
+}
+
+// fixTicketReference inserts the branch ticket according to the configured placement
+func fixTicketReference(lines []string, opts Options) []string {
+	if opts.Ticket == "" || len(lines) == 0 {
+		return lines
+	}
+
+	switch opts.TicketPlacement {
+	case TicketPrefix:
+		if h, ok := ParseHeader(lines[0]); ok && conventional(opts) {
+			h.Description = opts.Ticket + " " + h.Description
+			lines[0] = h.String()
+		} else {
+			lines[0] = opts.Ticket + " " + lines[0]
+		}
+		return lines
+	case TicketTrailer:
+		return strings.Split(trailer.Append(strings.Join(lines, "\n"), opts.TicketKey+": "+opts.Ticket), "\n")
+	default:
+		// A footer is always added as its own final paragraph
+		return append(lines, "", opts.TicketKey+": "+opts.Ticket)
+	}
+}
+
+func checkSubjectPeriod(lines []string, _ Options) []Violation {
+	if len(lines) > 0 && strings.HasSuffix(strings.TrimSpace(lines[0]), ".") {
+		return []Violation{{Line: 1, Message: "subject must not end with a period"}}
+	}
+	return nil
+}
+
+func fixSubjectPeriod(lines []string, _ Options) []string {
+	lines[0] = strings.TrimRight(strings.TrimSpace(lines[0]), ".")
+	return lines
+}
+
+func checkSubjectMaxLength(lines []string, opts Options) []Violation {
+	if opts.SubjectMaxLength > 0 && len(lines) > 0 && len([]rune(lines[0])) > opts.SubjectMaxLength {
+		return []Violation{{Line: 1, Message: fmt.Sprintf("subject is %d characters, limit is %d", len([]rune(lines[0])), opts.SubjectMaxLength)}}
+	}
+	return nil
+}
+
+func checkBodyLeadingBlank(lines []string, _ Options) []Violation {
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		return []Violation{{Line: 2, Message: "subject must be followed by a blank line"}}
+	}
+	return nil
+}
+
+func fixBodyLeadingBlank(lines []string, _ Options) []string {
+	return append([]string{lines[0], ""}, lines[1:]...)
+}
+
+func checkBodyMaxLineWidth(lines []string, opts Options) []Violation {
+	if opts.BodyMaxLineWidth <= 0 {
+		return nil
+	}
+	var out []Violation
+	for i := 1; i < len(lines); i++ {
+		if len([]rune(lines[i])) > opts.BodyMaxLineWidth && wrappable(lines[i]) {
+			out = append(out, Violation{Line: i + 1, Message: fmt.Sprintf("body line exceeds %d characters", opts.BodyMaxLineWidth)})
+		}
+	}
+	return out
+}
+
+func fixBodyMaxLineWidth(lines []string, opts Options) []string {
+	out := []string{lines[0]}
+	for _, l := range lines[1:] {
+		if len([]rune(l)) > opts.BodyMaxLineWidth && wrappable(l) {
+			out = append(out, wrapLine(l, opts.BodyMaxLineWidth)...)
+		} else {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+func checkMessageMaxLength(lines []string, opts Options) []Violation {
+	n := len([]rune(strings.Join(lines, "\n")))
+	if opts.MessageMaxLength > 0 && n > opts.MessageMaxLength {
+		return []Violation{{Message: fmt.Sprintf("message is %d characters, limit is %d", n, opts.MessageMaxLength)}}
+	}
+	return nil
+}
+
+// wrappable reports whether a long body line can be wrapped: trailers and single tokens such as URLs are left alone
+func wrappable(line string) bool {
+	return !trailerPattern.MatchString(line) && strings.Contains(strings.TrimSpace(line), " ")
+}
+
+// wrapLine splits a line at word boundaries, indenting continuation lines of list items
+func wrapLine(line string, width int) []string {
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	rest := strings.TrimLeft(line, " \t")
+	cont := indent
+	for _, marker := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(rest, marker) {
+			cont = indent + strings.Repeat(" ", len(marker))
+			break
+		}
+	}
+
+	var out []string
+	current := indent
+	for _, word := range strings.Fields(rest) {
+		if strings.TrimSpace(current) != "" && len([]rune(current))+1+len([]rune(word)) > width {
+			out = append(out, current)
+			current = cont
+		}
+		if strings.TrimSpace(current) != "" {
+			current += " "
+		}
+		current += word
+	}
+	return append(out, current)
+}
+
+func splitLines(message string) []string {
+	message = strings.ReplaceAll(message, "\r\n", "\n")
+	lines := strings.Split(message, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t")
+	}
+	return trimBlankLines(lines)
+}
+
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
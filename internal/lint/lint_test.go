@@ -0,0 +1,133 @@
+package lint
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// TestFix_RepairsCommonModelMistakes checks the automatic repairs applied to typical model output.
+func TestFix_RepairsCommonModelMistakes(t *testing.T) {
+	opts := OptionsFromConfig(&types.Config{}, "conventional", 500)
+
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "preamble and fences",
+			input: "Here's a commit message:\n```\nfeat: add login page\n```",
+			want:  "feat: add login page",
+		},
+		{
+			name:  "trailing period and type case",
+			input: "Feat(auth):add login page.",
+			want:  "feat(auth): add login page",
+		},
+		{
+			name:  "missing blank line and backticks",
+			input: "fix: handle empty `config`\nThe loader crashed on empty files",
+			want:  "fix: handle empty config\n\nThe loader crashed on empty files",
+		},
+		{
+			name:  "long body line is wrapped",
+			input: "docs: explain setup\n\n- a very long bullet point that keeps going well beyond the configured body width limit",
+			want:  "docs: explain setup\n\n- a very long bullet point that keeps going well beyond the configured\n  body width limit",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, remaining := Fix(tc.input, opts)
+			if got != tc.want {
+				t.Fatalf("Fix() = %q, want %q", got, tc.want)
+			}
+			if len(remaining) > 0 {
+				t.Fatalf("unexpected remaining violations: %v", remaining)
+			}
+		})
+	}
+}
+
+// TestCheck_ReportsUnfixableViolations ensures rules that need a re-prompt are reported as such.
+func TestCheck_ReportsUnfixableViolations(t *testing.T) {
+	opts := OptionsFromConfig(&types.Config{Lint: types.LintConfig{Scopes: []string{"api"}}}, "conventional", 500)
+
+	violations := Check("wip(server): rework the whole request handling pipeline for streaming responses and retries", opts)
+
+	var rulesHit []string
+	for _, v := range violations {
+		if v.Fixable {
+			t.Fatalf("violation %s should not be fixable", v)
+		}
+		rulesHit = append(rulesHit, v.Rule)
+	}
+
+	for _, want := range []string{RuleTypeEnum, RuleScopeEnum, RuleSubjectMaxLength} {
+		if !slices.Contains(rulesHit, want) {
+			t.Fatalf("expected %s violation, got %v", want, rulesHit)
+		}
+	}
+}
+
+// TestCheck_AnchoredForbiddenPhrase ensures "commit message:" is only forbidden as a preamble.
+func TestCheck_AnchoredForbiddenPhrase(t *testing.T) {
+	opts := OptionsFromConfig(&types.Config{}, "conventional", 500)
+
+	for _, v := range Check("fix(hook): validate commit message: reject empty subjects", opts) {
+		if v.Rule == RuleForbiddenPhrase {
+			t.Fatalf("unexpected violation %s", v)
+		}
+	}
+
+	got, remaining := Fix("Commit message:\nfix(hook): reject empty subjects", opts)
+	if got != "fix(hook): reject empty subjects" || len(remaining) > 0 {
+		t.Fatalf("Fix() = %q, %v", got, remaining)
+	}
+}
+
+// TestCheck_HandWrittenMarkdown ensures inline code is only reported in generated messages.
+func TestCheck_HandWrittenMarkdown(t *testing.T) {
+	message := "fix(config): handle the `Load` error path"
+	if v := Check(message, OptionsFromConfig(&types.Config{}, "conventional", 0)); len(v) > 0 {
+		t.Errorf("hand-written message: unexpected violations %v", v)
+	}
+	v := Check(message, OptionsFromConfig(&types.Config{}, "conventional", 500))
+	if len(v) != 1 || v[0].Rule != RuleNoMarkdown {
+		t.Errorf("generated message: violations = %v, want %s", v, RuleNoMarkdown)
+	}
+}
+
+// TestFix_InsertsBranchTicket checks every ticket placement.
+func TestFix_InsertsBranchTicket(t *testing.T) {
+	cases := []struct {
+		placement string
+		input     string
+		want      string
+	}{
+		{placement: TicketPrefix, input: "feat(api): add pagination", want: "feat(api): PROJ-7 add pagination"},
+		{placement: TicketFooter, input: "feat(api): add pagination", want: "feat(api): add pagination\n\nRefs: PROJ-7"},
+		{
+			placement: TicketTrailer,
+			input:     "feat(api): add pagination\n\nSigned-off-by: Jane <jane@example.com>",
+			want:      "feat(api): add pagination\n\nSigned-off-by: Jane <jane@example.com>\nRefs: PROJ-7",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.placement, func(t *testing.T) {
+			opts := OptionsFromConfig(&types.Config{Ticket: types.TicketConfig{Placement: tc.placement}}, "conventional", 500)
+			opts.Ticket = "PROJ-7"
+
+			got, remaining := Fix(tc.input, opts)
+			if got != tc.want {
+				t.Fatalf("Fix() = %q, want %q", got, tc.want)
+			}
+			if len(remaining) > 0 {
+				t.Fatalf("unexpected remaining violations: %v", remaining)
+			}
+		})
+	}
+}
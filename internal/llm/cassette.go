@@ -0,0 +1,374 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/edhuardotierrez/gommit/internal/colors"
+)
+
+// CassetteMode selects whether a Recorder talks to the network or replays saved traffic
+type CassetteMode string
+
+const (
+	// CassetteReplay serves responses from the cassette file and never touches the network
+	CassetteReplay CassetteMode = "replay"
+	// CassetteRecord forwards requests to the network and stores every interaction
+	CassetteRecord CassetteMode = "record"
+
+	redactedValue = "[REDACTED]"
+)
+
+// HTTPTransport, when set, is used by every provider client instead of http.DefaultTransport.
+// Tests point it at a Recorder so provider traffic can be replayed offline.
+var HTTPTransport http.RoundTripper
+
+// Headers and query parameters that carry credentials and must never be written to a cassette
+var (
+	sensitiveHeaders = []string{"Authorization", "X-Api-Key", "X-Goog-Api-Key", "Api-Key", "Openai-Organization"}
+	sensitiveParams  = []string{"key", "api_key"}
+)
+
+// CassetteRequest is the scrubbed form of an outgoing provider request
+type CassetteRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// CassetteResponse is the stored form of a provider response
+type CassetteResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body"`
+}
+
+// Interaction is a single request/response pair
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// Cassette is the on-disk format holding the recorded interactions in order. A synthetic cassette
+// was written by hand rather than recorded: its request bodies only hold the fields a request must
+// contain, and any other field is accepted.
+type Cassette struct {
+	Synthetic    bool          `json:"synthetic,omitempty"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records provider traffic to a cassette file or replays it
+type Recorder struct {
+	mode     CassetteMode
+	path     string
+	next     http.RoundTripper
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder creates a Recorder for the cassette at path. In replay mode the file must exist;
+// in record mode requests go through next (http.DefaultTransport when nil) and Save writes them out.
+func NewRecorder(path string, mode CassetteMode, next http.RoundTripper) (*Recorder, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	r := &Recorder{mode: mode, path: path, next: next}
+
+	switch mode {
+	case CassetteReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read cassette %s: %w", path, err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("could not parse cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	case CassetteRecord:
+	default:
+		return nil, fmt.Errorf("unknown cassette mode: %s", mode)
+	}
+
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == CassetteReplay {
+		return r.replay(req)
+	}
+
+	reqBody, err := drainBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := drainBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, newInteraction(req, reqBody, resp, respBody))
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// replay returns the first unused interaction matching the request method, scrubbed URL and body,
+// so a change in the prompt fails the test instead of replaying the old response. Bodies of a
+// synthetic cassette only need to be contained in the request.
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	reqBody, err := drainBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	target := scrubURL(req.URL)
+	body := normalizeBody(reqBody)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sameURL := false
+	for i, it := range r.cassette.Interactions {
+		if r.used[i] || it.Request.Method != req.Method || it.Request.URL != target {
+			continue
+		}
+		sameURL = true
+		if !r.bodyMatches(it.Request.Body, reqBody, body) {
+			continue
+		}
+		r.used[i] = true
+
+		header := make(http.Header, len(it.Response.Headers))
+		for k, v := range it.Response.Headers {
+			header.Set(k, v)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", it.Response.Status, http.StatusText(it.Response.Status)),
+			StatusCode:    it.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(it.Response.Body)),
+			ContentLength: int64(len(it.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	if sameURL {
+		return nil, fmt.Errorf("cassette %s has no unused interaction for %s %s with this body (re-record it if the request changed):\n%s", r.path, req.Method, target, body)
+	}
+	return nil, fmt.Errorf("cassette %s has no unused interaction for %s %s", r.path, req.Method, target)
+}
+
+// bodyMatches compares a recorded request body with the one sent, normalized as body
+func (r *Recorder) bodyMatches(recorded string, sent []byte, body string) bool {
+	if !r.cassette.Synthetic {
+		return normalizeBody([]byte(recorded)) == body
+	}
+	if strings.TrimSpace(recorded) == "" {
+		return true
+	}
+	var want, got any
+	if json.Unmarshal([]byte(recorded), &want) != nil || json.Unmarshal(sent, &got) != nil {
+		return false
+	}
+	return containsJSON(got, want)
+}
+
+// containsJSON reports whether got has every field of want with the same value; arrays must match
+// element by element
+func containsJSON(got, want any) bool {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range w {
+			if gv, ok := g[k]; !ok || !containsJSON(gv, v) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for i := range w {
+			if !containsJSON(g[i], w[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return got == want
+	}
+}
+
+// normalizeBody makes request bodies comparable: JSON is re-encoded with sorted keys and no
+// insignificant whitespace, anything else is compared as is
+func normalizeBody(body []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return string(body)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(data)
+}
+
+// Save writes the recorded interactions to the cassette file. It is a no-op in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != CassetteRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("could not create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("could not write cassette %s: %w", r.path, err)
+	}
+	return nil
+}
+
+// verboseTransport prints every provider interaction in cassette format (with secrets scrubbed)
+type verboseTransport struct {
+	next http.RoundTripper
+}
+
+func (t verboseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := drainBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := drainBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.MarshalIndent(newInteraction(req, reqBody, resp, respBody), "", "  "); err == nil {
+		colors.DescOutput("\n\n----------------------- Provider interaction:\n%s\n", data)
+	}
+
+	return resp, nil
+}
+
+// headerTransport sets a fixed header on every request, used to authenticate
+// clients whose SDK skips its own auth once a custom http.Client is supplied
+type headerTransport struct {
+	key   string
+	value string
+	next  http.RoundTripper
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(t.key, t.value)
+	return t.next.RoundTrip(req)
+}
+
+// newHTTPClient builds the http.Client shared by the provider clients
+func newHTTPClient(verbose bool) *http.Client {
+	transport := HTTPTransport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if verbose {
+		transport = verboseTransport{next: transport}
+	}
+	return &http.Client{Transport: transport}
+}
+
+// drainBody reads the body fully and replaces it with an in-memory copy
+func drainBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	_ = (*body).Close()
+	if err != nil {
+		return nil, fmt.Errorf("could not read http body: %w", err)
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func newInteraction(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) Interaction {
+	return Interaction{
+		Request: CassetteRequest{
+			Method:  req.Method,
+			URL:     scrubURL(req.URL),
+			Headers: scrubHeaders(req.Header),
+			Body:    string(reqBody),
+		},
+		Response: CassetteResponse{
+			Status:  resp.StatusCode,
+			Headers: map[string]string{"Content-Type": resp.Header.Get("Content-Type")},
+			Body:    string(respBody),
+		},
+	}
+}
+
+func scrubHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k := range h {
+		out[k] = h.Get(k)
+	}
+	for _, k := range sensitiveHeaders {
+		if _, ok := out[http.CanonicalHeaderKey(k)]; ok {
+			out[http.CanonicalHeaderKey(k)] = redactedValue
+		}
+	}
+	return out
+}
+
+func scrubURL(u *url.URL) string {
+	clean := *u
+	q := clean.Query()
+	for _, p := range sensitiveParams {
+		if q.Has(p) {
+			q.Set(p, redactedValue)
+		}
+	}
+	clean.RawQuery = q.Encode()
+	return clean.String()
+}
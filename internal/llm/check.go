@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// ProviderCheck is the outcome of CheckProvider
+type ProviderCheck struct {
+	Provider types.ProviderName
+	Model    string
+	Models   int           // models listed for the credentials
+	Latency  time.Duration // of the authenticated call
+}
+
+// CheckProvider verifies a provider configuration without generating anything: it makes the
+// cheapest authenticated call (listing the models) and checks that the configured model exists.
+// A successful check also refreshes the cached model list.
+func CheckProvider(provider types.ProviderName, pc types.ProviderConfig) (*ProviderCheck, error) {
+	meta, ok := ProviderByTitle(string(provider))
+	if !ok {
+		return nil, fmt.Errorf("unsupported LLM provider: %s", provider)
+	}
+	if slices.Contains(meta.Required, "api_key") && pc.APIKey == "" {
+		return nil, fmt.Errorf("api_key is not set")
+	}
+	if slices.Contains(meta.Required, "uri") && pc.URI == "" {
+		return nil, fmt.Errorf("uri is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), modelsTimeout)
+	defer cancel()
+
+	start := time.Now()
+	models, err := fetchModels(ctx, provider, pc)
+	check := &ProviderCheck{Provider: provider, Model: pc.Model, Models: len(models), Latency: time.Since(start)}
+
+	var statusErr *StatusError
+	switch {
+	case errors.As(err, &statusErr) && (statusErr.Code == http.StatusUnauthorized || statusErr.Code == http.StatusForbidden):
+		return check, fmt.Errorf("authentication failed, check the api_key (%s)", statusErr.Status)
+	case err != nil:
+		return check, fmt.Errorf("could not reach %s: %w", BaseURL(provider, pc), err)
+	}
+
+	writeModelsCache(modelsCachePath(provider, pc), ModelList{Provider: provider, Models: models, FetchedAt: time.Now()})
+
+	if pc.Model != "" && !hasModel(provider, models, pc.Model) {
+		return check, fmt.Errorf("model %q is not available (run `gommit models %s` to list them)", pc.Model, provider)
+	}
+	return check, nil
+}
+
+// hasModel reports whether model is in the listed models. Ollama tags default to ":latest", and
+// Anthropic aliases such as "claude-sonnet-4-0" or "-latest" resolve to dated snapshots the API
+// does not list, so only their family prefix is compared.
+func hasModel(provider types.ProviderName, models []string, model string) bool {
+	if slices.Contains(models, model) {
+		return true
+	}
+	switch provider {
+	case types.ProviderOllama:
+		return !strings.Contains(model, ":") && slices.Contains(models, model+":latest")
+	case types.ProviderAnthropic:
+		family := strings.TrimSuffix(strings.TrimSuffix(model, "-latest"), "-0")
+		for _, m := range models {
+			if strings.HasPrefix(m, family+"-") {
+				return true
+			}
+		}
+	}
+	return false
+}
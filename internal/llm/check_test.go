@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// TestCheckProvider runs the provider check against a local stand-in for the OpenAI and Ollama APIs.
+func TestCheckProvider(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			if r.Header.Get("Authorization") != "Bearer good-key" {
+				http.Error(w, `{"error":"invalid api key"}`, http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":[{"id":"gpt-4o-mini"},{"id":"gpt-5-mini"}]}`))
+		case "/api/tags":
+			_, _ = w.Write([]byte(`{"models":[{"name":"llama3:latest"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name     string
+		provider types.ProviderName
+		pc       types.ProviderConfig
+		wantErr  string
+	}{
+		{name: "ok", provider: types.ProviderOpenAI, pc: types.ProviderConfig{APIKey: "good-key", Model: "gpt-5-mini"}},
+		{name: "bad key", provider: types.ProviderOpenAI, pc: types.ProviderConfig{APIKey: "bad-key", Model: "gpt-5-mini"}, wantErr: "authentication failed"},
+		{name: "missing key", provider: types.ProviderOpenAI, pc: types.ProviderConfig{Model: "gpt-5-mini"}, wantErr: "api_key is not set"},
+		{name: "unknown model", provider: types.ProviderOpenAI, pc: types.ProviderConfig{APIKey: "good-key", Model: "gpt-9"}, wantErr: `model "gpt-9" is not available`},
+		{name: "ollama latest tag", provider: types.ProviderOllama, pc: types.ProviderConfig{Model: "llama3"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.pc.URI = server.URL
+			_, err := CheckProvider(tc.provider, tc.pc)
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("CheckProvider() failed: %v", err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("CheckProvider() error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+
+	// An unreachable endpoint fails without waiting for the model check
+	server.Close()
+	if _, err := CheckProvider(types.ProviderOllama, types.ProviderConfig{URI: server.URL, Model: "llama3"}); err == nil || !strings.Contains(err.Error(), "could not reach") {
+		t.Fatalf("CheckProvider() error = %v, want an unreachable endpoint", err)
+	}
+}
+
+// TestHasModel_AnthropicAliases checks that aliases match the dated snapshots the API lists.
+func TestHasModel_AnthropicAliases(t *testing.T) {
+	models := []string{"claude-sonnet-4-20250514", "claude-3-5-haiku-20241022"}
+	for model, want := range map[string]bool{
+		"claude-sonnet-4-0":         true,
+		"claude-3-5-haiku-latest":   true,
+		"claude-3-5-haiku-20241022": true,
+		"claude-opus-4-1":           false,
+	} {
+		if got := hasModel(types.ProviderAnthropic, models, model); got != want {
+			t.Errorf("hasModel(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
@@ -3,8 +3,10 @@ package llm
 import (
 	"context"
 	"fmt"
-	"os"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/anthropic"
@@ -12,10 +14,20 @@ import (
 	"github.com/tmc/langchaingo/llms/ollama"
 	"github.com/tmc/langchaingo/llms/openai"
 
+	"github.com/edhuardotierrez/gommit/internal/audit"
+	"github.com/edhuardotierrez/gommit/internal/cache"
 	"github.com/edhuardotierrez/gommit/internal/colors"
+	"github.com/edhuardotierrez/gommit/internal/enrich"
 	"github.com/edhuardotierrez/gommit/internal/git"
 	"github.com/edhuardotierrez/gommit/internal/globals"
+	"github.com/edhuardotierrez/gommit/internal/history"
+	"github.com/edhuardotierrez/gommit/internal/lint"
+	"github.com/edhuardotierrez/gommit/internal/policy"
+	"github.com/edhuardotierrez/gommit/internal/rules"
+	"github.com/edhuardotierrez/gommit/internal/scope"
+	"github.com/edhuardotierrez/gommit/internal/ticket"
 	"github.com/edhuardotierrez/gommit/internal/types"
+	"github.com/edhuardotierrez/gommit/internal/usage"
 )
 
 const (
@@ -81,42 +93,6 @@ var Providers = []types.ProviderTypes{
 	},
 }
 
-// GetAvailableModels returns a list of available models for a given provider
-func GetAvailableModels(provider types.ProviderName) []string {
-	switch provider {
-	case types.ProviderOpenAI:
-		return []string{
-			"gpt-5-nano",
-			"gpt-5-mini",
-			"gpt-5",
-			"gpt-4o-mini",
-			"gpt-4o",
-			"gpt-4.1-nano",
-			"gpt-4.1-mini",
-		}
-	case types.ProviderAnthropic:
-		return []string{
-			"claude-4-sonnet-latest",
-			"claude-3-5-sonnet-latest",
-			"claude-3-5-haiku-latest",
-			"claude-3-haiku-20240307",
-		}
-	case types.ProviderOllama:
-		return []string{
-			"llama3",
-			"mistral",
-		}
-	case types.ProviderGoogle:
-		return []string{
-			"gemini-2.5-flash-lite",
-			"gemini-2.5-flash",
-			"gemini-2.5-pro",
-		}
-	default:
-		return []string{}
-	}
-}
-
 // compressPrompt cleans and compresses a prompt string for LLM consumption
 func compressPrompt(prompt string) string {
 	// Split into lines and trim each line
@@ -175,9 +151,51 @@ func truncateDiff(diff string, truncateLines int, maxLineWidth int) string {
 	return strings.Join(firstPart, "\n") + "\n...[truncated]...\n" + strings.Join(lastPart, "\n")
 }
 
+// Result is the outcome of a commit message generation
+type Result struct {
+	Message  string
+	Warnings []lint.Violation // lint violations that could not be repaired
+	Cached   bool             // reused from an earlier generation for the same changes
+	CachedAt time.Time
+
+	// Token usage of all provider calls made for this message
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Prompt is the provider-independent part of a generation: the changes, the repository rules and
+// the context learned from the repository. It is built once by PreparePrompt and can generate
+// messages for several providers concurrently.
+type Prompt struct {
+	cfg      *types.Config
+	changes  []git.StagedChange
+	rules    *rules.Rules
+	policies []policy.Policy
+
+	instructions string // system prompt or free-text rules
+	history      string // examples from the repository's recent commits
+	summary      string // truncated diffs and code context
+	scope        string // scope inferred from the changed paths, for the conventional style
+	ticket       string // ticket from the branch name
+	branch       string
+	tree         string // staged tree, part of the cache key; empty when caching is off
+
+	budgetOnce sync.Once
+	budgetErr  error
+}
+
 // GenerateCommitMessage generates a commit message based on the staged changes
-func GenerateCommitMessage(cfg *types.Config, changes []git.StagedChange, provider string, selectedProvider types.ProviderConfig) (string, error) {
-	providerName := types.ProviderName(provider)
+func GenerateCommitMessage(cfg *types.Config, changes []git.StagedChange, provider string, selectedProvider types.ProviderConfig) (*Result, error) {
+	prompt, err := PreparePrompt(cfg, changes)
+	if err != nil {
+		return nil, err
+	}
+	return prompt.Generate(provider, selectedProvider)
+}
+
+// PreparePrompt reads the repository rules, history, branch ticket and code context for the staged changes
+func PreparePrompt(cfg *types.Config, changes []git.StagedChange) (*Prompt, error) {
+	p := &Prompt{cfg: cfg, changes: changes}
 
 	// Prepare the changes summary with truncated diffs
 	var summary strings.Builder
@@ -187,113 +205,472 @@ func GenerateCommitMessage(cfg *types.Config, changes []git.StagedChange, provid
 		fmt.Fprintf(&summary, "Diff:\n%s\n\n", truncatedDiff)
 	}
 
+	// Optional code context: enclosing declarations and earlier commits per file
+	if extra := enrich.Build(cfg.Context, changes); extra != "" {
+		summary.WriteString(extra)
+	}
+	p.summary = summary.String()
+
+	// Check for repository rules (.gommitrules)
+	repoRules, rulesErr := rules.Load()
+	if rulesErr != nil {
+		return nil, fmt.Errorf("error reading custom prompt: %w", rulesErr)
+	}
+	for _, key := range repoRules.DropLocked(cfg.IsLocked) {
+		colors.WarningOutput("⚠️ %s ignores %s: it is locked by the system configuration\n", repoRules.Path, key)
+	}
+	p.rules = repoRules
+
+	// Policies of the origin remote and .gommitrules, checked against each provider before anything is sent
+	var repoPolicy *types.PolicyConfig
+	var source string
+	if repoRules != nil {
+		repoPolicy, source = repoRules.Policy, repoRules.Path
+	}
+	if len(cfg.Policies) > 0 || repoPolicy != nil {
+		p.policies = policy.Applicable(cfg.Policies, repoPolicy, source, git.GetRemoteURL("origin"))
+	}
+
+	// Free-text rules replace the default prompt; structured rules are compiled on top of it
+	switch {
+	case repoRules != nil && !repoRules.Structured:
+		colors.SuccessOutput("Using your `.gommitrules` file\n\n")
+		p.instructions = compressPrompt(repoRules.Text + "\n\n" + securityPrompt)
+	case repoRules != nil:
+		colors.SuccessOutput("Using the rules in %s\n\n", repoRules.Path)
+		p.instructions = compressPrompt(systemPrompt + "\n\n" + repoRules.Prompt())
+	default:
+		p.instructions = compressPrompt(systemPrompt)
+	}
+
+	paths := make([]string, 0, len(changes))
+	for _, c := range changes {
+		paths = append(paths, c.Path)
+	}
+
+	// Learn the repository's conventions from its recent human-written commits
+	if historyCfg := historyConfig(cfg, repoRules); historyCfg.Enabled {
+		examples, historyErr := history.Examples(historyCfg, paths)
+		if historyErr != nil {
+			colors.WarningOutput("⚠️ Skipping commit history examples: %v\n", historyErr)
+		} else {
+			p.history = history.Prompt(examples, historyCfg.MaxChars)
+		}
+	}
+
+	// Infer the conventional-commit scope from the changed paths
+	p.scope = inferScope(cfg, repoRules, paths, "conventional")
+
+	// Reference the ticket from the branch name (e.g. feature/PROJ-1234-short-desc)
+	var err error
+	p.ticket, p.branch, err = ticket.FromBranch(cfg.Ticket)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ticket from branch: %w", err)
+	}
+
+	if !cfg.Cache.Disabled {
+		if tree, treeErr := git.WriteTree(); treeErr == nil {
+			p.tree = tree
+		}
+	}
+
+	return p, nil
+}
+
+// Generate writes the commit message with the given provider, then lints it and re-prompts the
+// model to repair what the linter cannot fix
+func (p *Prompt) Generate(provider string, selectedProvider types.ProviderConfig) (*Result, error) {
+	cfg := p.cfg
+	providerName := types.ProviderName(provider)
+
+	// Refuse providers and endpoints the repository's policies do not allow, before anything is sent
+	if err := checkPolicy(p.policies, providerName, selectedProvider); err != nil {
+		return nil, err
+	}
+
 	// add commit_style to the config
 	style := cfg.CommitStyle
 	if selectedProvider.CommitStyle != "" {
 		style = selectedProvider.CommitStyle
 	}
 
-	// Check for custom prompt
-	customPrompt, promptErr := readCustomPrompt()
-	if promptErr != nil {
-		return "", fmt.Errorf("error reading custom prompt: %w", promptErr)
-	}
-
-	// Use custom prompt if available, otherwise use default
-	var promptToUse string
-	if customPrompt != "" && len(customPrompt) > 100 {
-		promptToUse = compressPrompt(customPrompt + "\n\n" + securityPrompt)
-	} else {
-		promptToUse = compressPrompt(systemPrompt)
-	}
+	promptToUse := p.instructions
 
 	// add limit to system prompt
 	if limit, ok := messageLimitByStyle[style]; ok {
 		promptToUse = fmt.Sprintf("%s\n\n%s", promptToUse, fmt.Sprintf("You must generate a commit message under %d characters.", limit))
 	}
+	if p.history != "" {
+		promptToUse = fmt.Sprintf("%s\n\n%s", promptToUse, p.history)
+	}
 
-	// Compose prompt (system + user) for single-shot generation
-	userMessage := fmt.Sprintf("Please generate a commit message for the following changes (using '%s' as commit style):\n\n%s", style, summary.String())
+	// Pin the model to the inferred scope
+	var inferredScope string
+	if style == "conventional" {
+		inferredScope = p.scope
+	}
+	if inferredScope != "" {
+		promptToUse = fmt.Sprintf("%s\n\nUse %q as the scope of the commit subject (inferred from the changed paths).", promptToUse, inferredScope)
+	}
+	if p.ticket != "" {
+		promptToUse = fmt.Sprintf("%s\n\n%s", promptToUse, ticketInstruction(cfg, p.ticket, p.branch))
+	}
+
+	userMessage := userPrompt(style, p.summary)
 	combinedPrompt := compressPrompt(promptToUse + "\n\n" + userMessage)
 
 	if globals.VerboseMode {
 		colors.InfoOutput("\n\n----------------------- User input:\n" + userMessage)
 	}
 
+	// Reuse the message generated for the same staged tree, provider, model, style and prompt
+	var cacheEntry *cache.Entry
+	if p.tree != "" {
+		key := cache.Key(p.tree, provider, selectedProvider.Model, style, selectedProvider.Temperature, combinedPrompt)
+		if entry, ok := cache.Get(key, cache.MaxAge(cfg.Cache)); ok && !globals.RefreshCache {
+			return &Result{Message: entry.Message, Warnings: entry.Warnings, Cached: true, CachedAt: entry.CreatedAt}, nil
+		}
+		cacheEntry = &cache.Entry{Key: key, Tree: p.tree, Provider: provider, Model: selectedProvider.Model, Style: style}
+	}
+
 	// Validate required parameters for the provider
-	for _, p := range Providers {
-		if p.Name == providerName {
-			for _, required := range p.Required {
-				value := ""
-				switch required {
-				case "api_key":
-					value = selectedProvider.APIKey
-				case "uri":
-					value = selectedProvider.URI
-				}
-				if value == "" {
-					return "", fmt.Errorf("%s is required for %s provider", required, providerName)
-				}
+	if pt, ok := ProviderByTitle(provider); ok {
+		for _, required := range pt.Required {
+			value := ""
+			switch required {
+			case "api_key":
+				value = selectedProvider.APIKey
+			case "uri":
+				value = selectedProvider.URI
+			}
+			if value == "" {
+				return nil, fmt.Errorf("%s is required for %s provider", required, providerName)
 			}
-			break
 		}
 	}
 
+	// The budget is checked once, however many providers generate
+	p.budgetOnce.Do(func() { p.budgetErr = checkBudget(cfg) })
+	if p.budgetErr != nil {
+		return nil, p.budgetErr
+	}
+
 	// Initialize the LLM client based on the provider
-	var (
-		client llms.Model
-		err    error
-	)
-	switch providerName {
-	case types.ProviderOpenAI:
-		_ = os.Setenv("OPENAI_API_KEY", selectedProvider.APIKey)
-		client, err = openai.New()
+	endpoint, err := Endpoint(providerName, selectedProvider)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newClient(providerName, selectedProvider)
+	if err != nil {
+		return nil, err
+	}
 
-	case types.ProviderAnthropic:
-		_ = os.Setenv("ANTHROPIC_API_KEY", selectedProvider.APIKey)
-		client, err = anthropic.New()
+	result := &Result{}
 
-	case types.ProviderOllama:
-		_ = os.Setenv("OLLAMA_API_KEY", selectedProvider.APIKey)
-		_ = os.Setenv("OLLAMA_URI", selectedProvider.URI)
-		client, err = ollama.New(ollama.WithServerURL(selectedProvider.URI))
+	// Very large changesets: summarize groups of files with a cheap model first (map), then write the
+	// message from the summaries (reduce). The cache key above still covers the full diffs.
+	if needsSummary(cfg.Summarize, p.changes) {
+		summarized, err := summarizeChanges(cfg, client, providerName, endpoint, selectedProvider.Model, p.changes, result)
+		if err != nil {
+			return nil, err
+		}
+		userMessage = userPrompt(style, summarized)
+		combinedPrompt = compressPrompt(promptToUse + "\n\n" + userMessage)
+	}
 
-	case types.ProviderGoogle:
-		_ = os.Setenv("GOOGLE_API_KEY", selectedProvider.APIKey)
-		client, err = googleai.New(context.Background())
+	// Apply per-call options
+	var callOptions []llms.CallOption
+	if selectedProvider.Model != "" {
+		callOptions = append(callOptions, llms.WithModel(selectedProvider.Model))
+	}
+
+	temperature, _ := Temperature(provider, selectedProvider)
+	callOptions = append(callOptions, llms.WithTemperature(temperature))
+	if cfg.MaxTokens > 0 {
+		callOptions = append(callOptions, llms.WithMaxTokens(cfg.MaxTokens))
+	}
+
+	// The prompt is final: record it, with the address the client sends to, before it leaves the machine
+	if err := auditPrompt(cfg, audit.KindMessage, provider, endpoint, selectedProvider.Model, combinedPrompt); err != nil {
+		return nil, err
+	}
+
+	// Generate
+	response, err := generate(client, combinedPrompt, callOptions)
+	if err != nil {
+		return nil, err
+	}
+	recordUsage(cfg, result, provider, selectedProvider.Model, response)
+
+	// Validate the output: auto-fix what we can and re-prompt the model with the remaining violations
+	lintOptions := lint.OptionsFromConfig(cfg, style, messageLimitByStyle[style])
+	p.rules.ApplyLint(&lintOptions)
+	lintOptions.InferredScope = inferredScope
+	lintOptions.Ticket = p.ticket
+	message, violations := lint.Fix(response.Text, lintOptions)
+	for attempt := 0; attempt < lintRetries(cfg) && len(violations) > 0; attempt++ {
+		if globals.VerboseMode {
+			colors.InfoOutput("\n\n----------------------- Lint violations (retry %d):\n%s", attempt+1, lint.FormatViolations(violations))
+		}
+
+		repairPrompt := fmt.Sprintf("%s\n\nYour previous commit message was:\n%s\n\nIt violates these rules:\n%s\nReturn only the corrected commit message.", combinedPrompt, message, lint.FormatViolations(violations))
+		if err := auditPrompt(cfg, audit.KindRepair, provider, endpoint, selectedProvider.Model, repairPrompt); err != nil {
+			colors.WarningOutput("⚠️ %v\n", err)
+			break
+		}
+		retried, err := generate(client, repairPrompt, callOptions)
+		if err != nil {
+			colors.WarningOutput("⚠️ Could not repair the lint violations: %v\n", err)
+			break
+		}
+		recordUsage(cfg, result, provider, selectedProvider.Model, retried)
+
+		// Keep the retry only when it is an improvement
+		if retriedMessage, retriedViolations := lint.Fix(retried.Text, lintOptions); len(retriedViolations) < len(violations) {
+			message, violations = retriedMessage, retriedViolations
+		}
+	}
+
+	if strings.TrimSpace(message) == "" {
+		return nil, fmt.Errorf("no commit message content found. check your provider configuration")
+	}
+
+	if cacheEntry != nil {
+		cacheEntry.Message, cacheEntry.Warnings = message, violations
+		if err := cache.Put(*cacheEntry, cache.MaxAge(cfg.Cache)); err != nil && globals.VerboseMode {
+			colors.WarningOutput("⚠️ Could not cache the commit message: %v\n", err)
+		}
+	}
+
+	result.Message, result.Warnings = message, violations
+	return result, nil
+}
+
+// checkPolicy applies the policies matching the origin remote and the policy of .gommitrules to the
+// provider and its endpoint
+func checkPolicy(policies []policy.Policy, provider types.ProviderName, pc types.ProviderConfig) error {
+	if len(policies) == 0 {
+		return nil
+	}
+	endpoint, err := Endpoint(provider, pc)
+	if err != nil {
+		return err
+	}
+	return policy.Check(policies, string(provider), endpoint)
+}
+
+// inferScope maps the changed paths to a single scope using the .gommitrules scopes, the configured
+// scope rules, workspace members and the top-level package, in that order. It only applies to the
+// conventional style, and an inferred scope outside the declared scope list is discarded.
+func inferScope(cfg *types.Config, repoRules *rules.Rules, paths []string, style string) string {
+	if style != "conventional" || cfg.Scope.Disabled {
+		return ""
+	}
+
+	var scopeRules []scope.Rule
+	var allowed []string
+	if repoRules != nil {
+		for _, s := range repoRules.Scopes {
+			scopeRules = append(scopeRules, scope.Rule{Scope: s.Name, Paths: s.Paths})
+		}
+		allowed = repoRules.ScopeNames()
+	}
+	for _, r := range cfg.Scope.Rules {
+		scopeRules = append(scopeRules, scope.Rule{Scope: r.Scope, Paths: r.Paths})
+	}
+	if len(allowed) == 0 {
+		allowed = cfg.Lint.Scopes
+	}
+
+	inferred := scope.New(git.GetRootPath(), scopeRules, !cfg.Scope.NoFallback).Infer(paths)
+	if inferred != "" && len(allowed) > 0 && !slices.Contains(allowed, inferred) {
+		return ""
+	}
+	return inferred
+}
 
+// historyConfig returns the history settings, with the repository's .gommitrules taking precedence
+func historyConfig(cfg *types.Config, repoRules *rules.Rules) types.HistoryConfig {
+	if repoRules != nil && repoRules.History != nil {
+		return *repoRules.History
+	}
+	return cfg.History
+}
+
+// ticketInstruction tells the model where the branch ticket goes; the linter inserts it if the model forgets
+func ticketInstruction(cfg *types.Config, id, branch string) string {
+	key := cfg.Ticket.TrailerKey
+	if key == "" {
+		key = lint.DefaultTicketKey
+	}
+	switch cfg.Ticket.Placement {
+	case lint.TicketPrefix:
+		return fmt.Sprintf("The current branch is %s: start the subject description with the ticket %s.", branch, id)
 	default:
-		return "", fmt.Errorf("unsupported LLM provider: %s", provider)
+		return fmt.Sprintf("The current branch is %s: end the message with the line \"%s: %s\".", branch, key, id)
 	}
+}
 
+// userPrompt asks for the commit message of the described changes
+func userPrompt(style, changes string) string {
+	return fmt.Sprintf("Please generate a commit message for the following changes (using '%s' as commit style):\n\n%s", style, changes)
+}
+
+// generate runs a single-shot completion for the prompt
+func generate(client llms.Model, prompt string, callOptions []llms.CallOption) (*generation, error) {
+	started := time.Now()
+	resp, err := client.GenerateContent(context.Background(), []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, prompt)}, callOptions...)
 	if err != nil {
-		return "", fmt.Errorf("error initializing LLM client: %w", err)
+		return nil, fmt.Errorf("error generating commit message: %w", err)
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Content) == "" {
+		return nil, fmt.Errorf("no commit message content found. check your provider configuration")
 	}
 
-	// Apply per-call options
-	var callOptions []llms.CallOption
-	if selectedProvider.Model != "" {
-		callOptions = append(callOptions, llms.WithModel(selectedProvider.Model))
+	choice := resp.Choices[0]
+	return &generation{
+		Text:             choice.Content,
+		PromptTokens:     tokenCount(choice.GenerationInfo, "PromptTokens", "InputTokens", "input_tokens"),
+		CompletionTokens: tokenCount(choice.GenerationInfo, "CompletionTokens", "OutputTokens", "output_tokens"),
+		Latency:          time.Since(started),
+	}, nil
+}
+
+// generation is a model response with its token usage and latency
+type generation struct {
+	Text             string
+	PromptTokens     int
+	CompletionTokens int
+	Latency          time.Duration
+}
+
+// tokenCount reads a token count from the provider-specific generation info (the key names differ per provider)
+func tokenCount(info map[string]any, keys ...string) int {
+	for _, k := range keys {
+		switch v := info[k].(type) {
+		case int:
+			return v
+		case int32:
+			return int(v)
+		case int64:
+			return int(v)
+		case float64:
+			return int(v)
+		}
 	}
+	return 0
+}
 
-	// Temperature policy: some models accept only the provider's default temperature
-	if requiresDefaultTemperature(providerName, selectedProvider.Model) {
-		// Force default temperature to 1.0 for these models
-		callOptions = append(callOptions, llms.WithTemperature(1.0))
-	} else if selectedProvider.Temperature > 0 {
-		callOptions = append(callOptions, llms.WithTemperature(selectedProvider.Temperature))
+// recordUsage appends a provider call to the usage log and adds it to the result totals
+func recordUsage(cfg *types.Config, result *Result, provider, model string, gen *generation) {
+	result.PromptTokens += gen.PromptTokens
+	result.CompletionTokens += gen.CompletionTokens
+	if cfg.Usage.Disabled {
+		return
 	}
 
-	// Generate
-	response, err := llms.GenerateFromSinglePrompt(context.Background(), client, combinedPrompt, callOptions...)
+	record := usage.Record{
+		Time:             time.Now(),
+		Repo:             git.GetRootPath(),
+		Provider:         provider,
+		Model:            model,
+		PromptTokens:     gen.PromptTokens,
+		CompletionTokens: gen.CompletionTokens,
+		LatencyMs:        gen.Latency.Milliseconds(),
+	}
+	if err := usage.Append(record); err != nil && globals.VerboseMode {
+		colors.WarningOutput("⚠️ Could not write the usage log: %v\n", err)
+	}
+}
+
+// auditPrompt records a prompt in the audit log before it is sent. The log is opt-in; once it is
+// enabled, a prompt that cannot be recorded is not sent.
+func auditPrompt(cfg *types.Config, kind, provider, endpoint, model, prompt string) error {
+	if !cfg.Audit.Enabled {
+		return nil
+	}
+	record := audit.Record{Time: time.Now(), Repo: git.GetRootPath(), Provider: provider, Endpoint: endpoint, Model: model, Kind: kind}
+	if err := audit.Log(cfg.Audit, record, prompt); err != nil {
+		return fmt.Errorf("%w (the prompt was not sent)", err)
+	}
+	return nil
+}
+
+// checkBudget warns, or fails when configured to block, once the estimated monthly spend reaches the budget
+func checkBudget(cfg *types.Config) error {
+	spent, exceeded, err := usage.CheckBudget(cfg.Usage)
+	if err != nil || !exceeded {
+		return nil
+	}
+	if cfg.Usage.BudgetAction == usage.BudgetBlock {
+		return fmt.Errorf("monthly budget of $%.2f reached (estimated spend $%.2f); raise usage.monthly_budget or set usage.budget_action to %q", cfg.Usage.MonthlyBudget, spent, usage.BudgetWarn)
+	}
+	colors.WarningOutput("⚠️ Monthly budget of $%.2f reached (estimated spend $%.2f)\n", cfg.Usage.MonthlyBudget, spent)
+	return nil
+}
+
+// lintRetries returns how many times the model is re-prompted to repair lint violations
+func lintRetries(cfg *types.Config) int {
+	switch {
+	case cfg.Lint.MaxRetries < 0:
+		return 0
+	case cfg.Lint.MaxRetries == 0:
+		return lint.DefaultMaxRetries
+	default:
+		return cfg.Lint.MaxRetries
+	}
+}
+
+// newClient initializes the LLM client for the given provider. Every client shares the
+// http.Client from newHTTPClient so traffic can be recorded, replayed or dumped in verbose mode, and
+// sends to Endpoint, the address the policies and the audit log see. Credentials are passed as
+// options, never through the environment, so clients for several providers can be built concurrently.
+func newClient(providerName types.ProviderName, selectedProvider types.ProviderConfig) (llms.Model, error) {
+	httpClient := newHTTPClient(globals.VerboseMode)
+	endpoint, err := Endpoint(providerName, selectedProvider)
 	if err != nil {
-		return "", fmt.Errorf("error generating commit message: %w", err)
+		return nil, err
+	}
+
+	var client llms.Model
+	switch providerName {
+	case types.ProviderOpenAI:
+		client, err = openai.New(openai.WithToken(selectedProvider.APIKey), openai.WithBaseURL(endpoint+"/v1"), openai.WithHTTPClient(httpClient))
+
+	case types.ProviderAnthropic:
+		client, err = anthropic.New(anthropic.WithToken(selectedProvider.APIKey), anthropic.WithBaseURL(endpoint+"/v1"), anthropic.WithHTTPClient(httpClient))
+
+	case types.ProviderOllama:
+		// The Ollama client has no API key option; the key is for servers behind an authenticating proxy
+		if selectedProvider.APIKey != "" {
+			httpClient.Transport = headerTransport{key: "Authorization", value: "Bearer " + selectedProvider.APIKey, next: httpClient.Transport}
+		}
+		client, err = ollama.New(ollama.WithServerURL(endpoint), ollama.WithHTTPClient(httpClient))
+
+	case types.ProviderGoogle:
+		// The Google SDK skips its own API key handling when given an http.Client
+		httpClient.Transport = headerTransport{key: "X-Goog-Api-Key", value: selectedProvider.APIKey, next: httpClient.Transport}
+		client, err = googleai.New(context.Background(), googleai.WithAPIKey(selectedProvider.APIKey), googleai.WithHTTPClient(httpClient))
+
+	default:
+		return nil, fmt.Errorf("unsupported LLM provider: %s", providerName)
 	}
-	if strings.TrimSpace(response) == "" {
-		return "", fmt.Errorf("no commit message content found. check your provider configuration")
+
+	if err != nil {
+		return nil, fmt.Errorf("error initializing LLM client: %w", err)
 	}
 
-	return response, nil
+	return client, nil
+}
+
+// Temperature returns the temperature sent to the provider and whether the model fixes it. Some
+// models accept only the provider's default temperature (1.0), whatever the config says; 0 is a
+// valid temperature, as the config loader only fills in the default when it is missing.
+func Temperature(provider string, pc types.ProviderConfig) (float64, bool) {
+	if requiresDefaultTemperature(types.ProviderName(provider), pc.Model) {
+		return 1.0, true
+	}
+	return pc.Temperature, false
 }
 
 // requiresDefaultTemperature indicates whether a given provider/model only supports the default
@@ -316,23 +693,3 @@ func requiresDefaultTemperature(provider types.ProviderName, model string) bool
 	}
 	return false
 }
-
-// readCustomPrompt reads the .gommitrules file from the current directory if it exists
-func readCustomPrompt() (string, error) {
-	if _, err := os.Stat(".gommitrules"); os.IsNotExist(err) {
-		return "", nil
-	}
-
-	content, err := os.ReadFile(".gommitrules")
-	if err != nil {
-		return "", err
-	}
-
-	if len(content) == 0 {
-		return "", nil
-	}
-
-	colors.SuccessOutput("Using your `.gommitrules` file\n\n")
-
-	return string(content), nil
-}
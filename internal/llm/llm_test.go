@@ -1,12 +1,21 @@
 package llm
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/edhuardotierrez/gommit/internal/audit"
 	"github.com/edhuardotierrez/gommit/internal/env"
 	"github.com/edhuardotierrez/gommit/internal/git"
+	"github.com/edhuardotierrez/gommit/internal/policy"
 	"github.com/edhuardotierrez/gommit/internal/types"
 )
 
@@ -27,25 +36,52 @@ func TestGetAvailableModels_Sanity(t *testing.T) {
 	}
 }
 
-// TestGenerateCommitMessage_Minimal runs a minimal integration for each provider if env is present.
-// It uses a tiny diff and a short custom prompt file (>=101 chars to be picked up by code logic).
+// useCassette routes provider traffic through the cassette for name. Cassettes are replayed
+// offline by default; set GOMMIT_RECORD_CASSETTES=1 (with real credentials) to re-record them.
+func useCassette(t *testing.T, name string, record bool) {
+	t.Helper()
+
+	mode := CassetteReplay
+	if record {
+		mode = CassetteRecord
+	}
+
+	rec, err := NewRecorder(filepath.Join("testdata", "cassettes", name+".json"), mode, nil)
+	if err != nil {
+		t.Fatalf("failed to load cassette for %s: %v", name, err)
+	}
+
+	HTTPTransport = rec
+	t.Cleanup(func() {
+		HTTPTransport = nil
+		if err := rec.Save(); err != nil {
+			t.Errorf("failed to save cassette for %s: %v", name, err)
+		}
+	})
+}
+
+// TestGenerateCommitMessage_Minimal runs a minimal integration for each provider against recorded cassettes.
+// It uses a tiny diff and a short free-text custom prompt file.
 func TestGenerateCommitMessage_Minimal(t *testing.T) {
-	// Create minimal custom prompt used by GenerateCommitMessage when >100 chars.
-	// Keep it compact but above the threshold to exercise the custom prompt path.
+	// Create a minimal free-text custom prompt to exercise the custom prompt path.
 	custom := strings.Repeat("Keep commits concise. Avoid secrets. Use imperative mood. ", 3)
-	if len(custom) <= 100 {
-		t.Fatalf("custom prompt should exceed 100 chars, got %d", len(custom))
-	}
 	if err := os.WriteFile(".gommitrules", []byte(custom), 0o644); err != nil {
 		t.Fatalf("failed to write .gommitrules: %v", err)
 	}
 	t.Cleanup(func() { _ = os.Remove(".gommitrules") })
 
-	// Common minimal config and changes
+	// Keep the usage log out of the user's state directory
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	// Common minimal config and changes; nothing in the prompt may depend on the checkout (branch, history)
 	cfg := &types.Config{
 		CommitStyle:   "simple",
 		TruncateLines: 3,
 		MaxLineWidth:  60,
+		Cache:         types.CacheConfig{Disabled: true},
+		Scope:         types.ScopeConfig{Disabled: true},
+		History:       types.HistoryConfig{Enabled: false},
+		Context:       types.ContextConfig{Enabled: false},
 	}
 	changes := []git.StagedChange{{
 		Path:   "file.txt",
@@ -53,58 +89,323 @@ func TestGenerateCommitMessage_Minimal(t *testing.T) {
 		Diff:   "diff --git a/file.txt b/file.txt\n--- a/file.txt\n+++ b/file.txt\n+hello world\n",
 	}}
 
-	// Table of providers with env requirements and optional URI key.
+	// Table of providers with env requirements, optional URI key and the model pinned in the cassette.
 	type caseDef struct {
 		name     string
 		provider types.ProviderName
+		model    string
 		apiEnv   string
 		uriEnv   string
 	}
 	cases := []caseDef{
-		{name: "openai", provider: types.ProviderOpenAI, apiEnv: "OPENAI_API_KEY"},
-		{name: "anthropic", provider: types.ProviderAnthropic, apiEnv: "ANTHROPIC_API_KEY"},
-		{name: "ollama", provider: types.ProviderOllama, apiEnv: "OLLAMA_API_KEY", uriEnv: "OLLAMA_URI"},
-		{name: "google", provider: types.ProviderGoogle, apiEnv: "GOOGLE_API_KEY"},
+		{name: "openai", provider: types.ProviderOpenAI, model: "gpt-4o-mini", apiEnv: "OPENAI_API_KEY"},
+		{name: "anthropic", provider: types.ProviderAnthropic, model: "claude-3-5-haiku-latest", apiEnv: "ANTHROPIC_API_KEY"},
+		{name: "ollama", provider: types.ProviderOllama, model: "llama3", apiEnv: "OLLAMA_API_KEY", uriEnv: "OLLAMA_URI"},
+		{name: "google", provider: types.ProviderGoogle, model: "gemini-2.5-flash-lite", apiEnv: "GOOGLE_API_KEY"},
 	}
 
 	// Load env file if present
 	env.LoadFile()
+	record := env.GetString("GOMMIT_RECORD_CASSETTES") != ""
 
 	for _, tc := range cases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 
-			// Skip if required env vars not present
-			if tc.apiEnv != "" && env.GetString(tc.apiEnv) == "" {
-				t.Skipf("skipping %s: missing %s", tc.name, tc.apiEnv)
+			// Replayed cassettes only need placeholder credentials
+			sel := types.ProviderConfig{
+				APIKey:      "test-key",
+				Model:       tc.model,
+				Temperature: 0.0,
 			}
-			if tc.uriEnv != "" && env.GetString(tc.uriEnv) == "" {
-				t.Skipf("skipping %s: missing %s", tc.name, tc.uriEnv)
+			if tc.provider == types.ProviderOllama {
+				sel.URI = "http://localhost:11434"
 			}
 
-			models := GetAvailableModels(tc.provider)
-			if len(models) == 0 {
-				t.Fatalf("no models for provider %s", tc.provider)
+			if record {
+				// Skip if required env vars not present
+				if tc.apiEnv != "" && env.GetString(tc.apiEnv) == "" {
+					t.Skipf("skipping %s: missing %s", tc.name, tc.apiEnv)
+				}
+				if tc.uriEnv != "" && env.GetString(tc.uriEnv) == "" {
+					t.Skipf("skipping %s: missing %s", tc.name, tc.uriEnv)
+				}
+				sel.APIKey = env.GetString(tc.apiEnv)
+				if tc.uriEnv != "" {
+					sel.URI = env.GetString(tc.uriEnv)
+				}
 			}
-			selectedModel := models[0]
 
-			// Prepare provider-specific config
-			sel := types.ProviderConfig{
-				APIKey:      env.GetString(tc.apiEnv),
-				URI:         env.GetString(tc.uriEnv),
-				Model:       selectedModel,
-				Temperature: 0.0,
-			}
+			useCassette(t, tc.name, record)
 
-			msg, err := GenerateCommitMessage(cfg, changes, string(tc.provider), sel)
+			result, err := GenerateCommitMessage(cfg, changes, string(tc.provider), sel)
 			if err != nil {
 				t.Fatalf("GenerateCommitMessage failed for %s: %v", tc.name, err)
 			}
+			msg := result.Message
 			if strings.TrimSpace(msg) == "" {
 				t.Fatalf("empty commit message for %s", tc.name)
 			}
+			if result.PromptTokens == 0 || result.CompletionTokens == 0 {
+				t.Fatalf("token usage not captured for %s: %+v", tc.name, result)
+			}
 			t.Logf("[model=%s] generated message: %s", tc.name, msg)
 			t.Log("-----------------------------")
 		})
 	}
 }
+
+// TestRecorder_ScrubsSecrets ensures credentials never reach a cassette file.
+func TestRecorder_ScrubsSecrets(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/v1/models?key=secret-key&alt=json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret-key")
+	req.Header.Set("X-Api-Key", "secret-key")
+
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	it := newInteraction(req, []byte("{}"), resp, []byte("{}"))
+
+	data, err := json.Marshal(it)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-key") {
+		t.Fatalf("cassette interaction leaks credentials: %s", data)
+	}
+}
+
+// TestRecorder_ReplayMatchesBody ensures a replayed interaction needs the recorded request body, or
+// for a synthetic cassette the fields it lists.
+func TestRecorder_ReplayMatchesBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	write := func(synthetic bool, body string) {
+		t.Helper()
+		data, err := json.Marshal(Cassette{Synthetic: synthetic, Interactions: []Interaction{{
+			Request:  CassetteRequest{Method: http.MethodPost, URL: "https://example.com/v1/chat", Body: body},
+			Response: CassetteResponse{Status: http.StatusOK, Body: "{}"},
+		}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	send := func(body string) error {
+		rec, err := NewRecorder(path, CassetteReplay, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPost, "https://example.com/v1/chat", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = rec.RoundTrip(req)
+		return err
+	}
+	write(false, `{"model": "m", "prompt": "hello"}`)
+	if err := send(`{"prompt":"hello","model":"m"}`); err != nil {
+		t.Errorf("same body in another key order: %v", err)
+	}
+	if err := send(`{"prompt":"hello again","model":"m"}`); err == nil {
+		t.Error("a different body replayed the recorded response")
+	}
+
+	write(true, `{"model": "m"}`)
+	if err := send(`{"prompt":"anything","model":"m"}`); err != nil {
+		t.Errorf("synthetic cassette with the listed fields: %v", err)
+	}
+	if err := send(`{"prompt":"anything","model":"other"}`); err == nil {
+		t.Error("a synthetic cassette replayed a request for another model")
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// TestGenerateCommitMessage_Policy checks that a provider refused by a policy is never called.
+func TestGenerateCommitMessage_Policy(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	requests := 0
+	HTTPTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		return nil, http.ErrHandlerTimeout
+	})
+	t.Cleanup(func() { HTTPTransport = nil })
+
+	changes := []git.StagedChange{{Path: "file.txt", Status: "M", Diff: "+hello\n"}}
+	localOnly := []types.PolicyConfig{{Allow: []types.PolicyRule{{Provider: "ollama", Endpoint: "localhost"}}}}
+	for _, tc := range []struct {
+		name     string
+		provider types.ProviderName
+		pc       types.ProviderConfig
+	}{
+		{"hosted provider", types.ProviderOpenAI, types.ProviderConfig{APIKey: "test-key", Model: "gpt-4o-mini"}},
+		{"remote ollama", types.ProviderOllama, types.ProviderConfig{URI: "https://ollama.example.com", Model: "llama3"}},
+	} {
+		cfg := &types.Config{CommitStyle: "simple", Cache: types.CacheConfig{Disabled: true}, Policies: localOnly}
+		_, err := GenerateCommitMessage(cfg, changes, string(tc.provider), tc.pc)
+		var denied *policy.DeniedError
+		if !errors.As(err, &denied) {
+			t.Errorf("%s: error = %v, want *policy.DeniedError", tc.name, err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("%d request(s) sent to a refused provider", requests)
+	}
+}
+
+// TestGenerateCommitMessage_Audit checks that the prompt sent is recorded, and stored when asked.
+func TestGenerateCommitMessage_Audit(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	useCassette(t, "openai", false)
+
+	cfg := &types.Config{
+		CommitStyle:   "simple",
+		TruncateLines: 3,
+		MaxLineWidth:  60,
+		Cache:         types.CacheConfig{Disabled: true},
+		Scope:         types.ScopeConfig{Disabled: true},
+		History:       types.HistoryConfig{Enabled: false},
+		Context:       types.ContextConfig{Enabled: false},
+		Audit:         types.AuditConfig{Enabled: true, StorePrompts: true},
+	}
+	changes := []git.StagedChange{{Path: "file.txt", Status: "M", Diff: "+hello world\n"}}
+	sel := types.ProviderConfig{APIKey: "test-key", Model: "gpt-4o-mini"}
+	if _, err := GenerateCommitMessage(cfg, changes, string(types.ProviderOpenAI), sel); err != nil {
+		t.Fatalf("GenerateCommitMessage failed: %v", err)
+	}
+
+	records, err := audit.Read(time.Time{})
+	if err != nil || len(records) == 0 {
+		t.Fatalf("audit.Read() = %v, %v", records, err)
+	}
+	r := records[0]
+	if r.Provider != "openai" || r.Endpoint != "https://api.openai.com" || r.Model != "gpt-4o-mini" || r.Kind != audit.KindMessage || !r.Stored {
+		t.Errorf("audit record = %+v", r)
+	}
+	prompt, hash, err := audit.Prompt(r.Hash[:12])
+	if err != nil || hash != r.Hash || audit.Hash(prompt) != r.Hash || len(prompt) != r.Bytes || !strings.Contains(prompt, "hello world") {
+		t.Errorf("stored prompt does not match the record: %v", err)
+	}
+}
+
+// TestGenerateCommitMessage_Endpoint checks that a configured uri is where the request goes, whatever
+// OPENAI_BASE_URL says, and that the policy and the audit log see the same endpoint.
+func TestGenerateCommitMessage_Endpoint(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("OPENAI_BASE_URL", "https://api.openai.com/v1")
+	var hosts, paths []string
+	HTTPTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		hosts = append(hosts, r.URL.Host)
+		paths = append(paths, r.URL.Path)
+		body := `{"choices":[{"index":0,"message":{"role":"assistant","content":"Update file"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":2}}`
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})
+	t.Cleanup(func() { HTTPTransport = nil })
+
+	cfg := &types.Config{
+		CommitStyle: "simple",
+		Cache:       types.CacheConfig{Disabled: true},
+		Policies:    []types.PolicyConfig{{Allow: []types.PolicyRule{{Endpoint: "*.corp.internal"}}}},
+		Audit:       types.AuditConfig{Enabled: true},
+	}
+	changes := []git.StagedChange{{Path: "file.txt", Status: "M", Diff: "+hello\n"}}
+	sel := types.ProviderConfig{APIKey: "test-key", Model: "gpt-4o-mini", URI: "https://llm.corp.internal"}
+	if _, err := GenerateCommitMessage(cfg, changes, string(types.ProviderOpenAI), sel); err != nil {
+		t.Fatalf("GenerateCommitMessage failed: %v", err)
+	}
+	if len(hosts) == 0 || hosts[0] != "llm.corp.internal" {
+		t.Errorf("requests sent to %v, want llm.corp.internal", hosts)
+	}
+	if records, err := audit.Read(time.Time{}); err != nil || len(records) == 0 || records[0].Endpoint != "https://llm.corp.internal" {
+		t.Errorf("audit records = %+v, %v; want the endpoint https://llm.corp.internal", records, err)
+	}
+
+	// a uri documented with its version prefix does not get it twice
+	sel.URI = "https://llm.corp.internal/v1/"
+	paths = nil
+	if _, err := GenerateCommitMessage(cfg, changes, string(types.ProviderOpenAI), sel); err != nil {
+		t.Fatalf("GenerateCommitMessage failed: %v", err)
+	}
+	if len(paths) == 0 || paths[0] != "/v1/chat/completions" {
+		t.Errorf("requests sent to %v, want /v1/chat/completions", paths)
+	}
+
+	// without a uri, the default endpoint is refused by the same policy
+	sel.URI = ""
+	var denied *policy.DeniedError
+	if _, err := GenerateCommitMessage(cfg, changes, string(types.ProviderOpenAI), sel); !errors.As(err, &denied) {
+		t.Errorf("error = %v, want *policy.DeniedError", err)
+	}
+}
+
+// TestGenerateCommitMessage_RequiredFields checks that a provider missing a required field fails
+// before anything is sent.
+func TestGenerateCommitMessage_RequiredFields(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("OPENAI_API_KEY", "from-env")
+	HTTPTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		t.Errorf("unexpected request to %s", r.URL)
+		return nil, errors.New("unexpected request")
+	})
+	t.Cleanup(func() { HTTPTransport = nil })
+
+	cfg := &types.Config{CommitStyle: "simple", Cache: types.CacheConfig{Disabled: true}}
+	changes := []git.StagedChange{{Path: "file.txt", Status: "M", Diff: "+hello\n"}}
+	sel := types.ProviderConfig{Model: "gpt-4o-mini"}
+	if _, err := GenerateCommitMessage(cfg, changes, string(types.ProviderOpenAI), sel); err == nil || !strings.Contains(err.Error(), "api_key is required") {
+		t.Errorf("error = %v, want api_key is required", err)
+	}
+}
+
+// TestPrompt_GenerateConcurrently checks that a prepared prompt serves several providers at once,
+// each request carrying its own credentials, without touching the environment.
+func TestPrompt_GenerateConcurrently(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("OPENAI_API_KEY", "from-env")
+	var mu sync.Mutex
+	auth := map[string]string{}
+	HTTPTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		auth[r.URL.Host] = r.Header.Get("Authorization")
+		mu.Unlock()
+		body := `{"choices":[{"index":0,"message":{"role":"assistant","content":"Update file"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":2}}`
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})
+	t.Cleanup(func() { HTTPTransport = nil })
+
+	cfg := &types.Config{CommitStyle: "simple", Cache: types.CacheConfig{Disabled: true}}
+	prompt, err := PreparePrompt(cfg, []git.StagedChange{{Path: "file.txt", Status: "M", Diff: "+hello\n"}})
+	if err != nil {
+		t.Fatalf("PreparePrompt failed: %v", err)
+	}
+
+	keys := map[string]string{"a.example.com": "key-a", "b.example.com": "key-b", "c.example.com": "key-c"}
+	var wg sync.WaitGroup
+	for host, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sel := types.ProviderConfig{APIKey: key, Model: "gpt-4o-mini", URI: "https://" + host}
+			if _, err := prompt.Generate(string(types.ProviderOpenAI), sel); err != nil {
+				t.Errorf("%s: Generate failed: %v", host, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for host, key := range keys {
+		if auth[host] != "Bearer "+key {
+			t.Errorf("%s got Authorization %q, want the key %s", host, auth[host], key)
+		}
+	}
+	if got := os.Getenv("OPENAI_API_KEY"); got != "from-env" {
+		t.Errorf("OPENAI_API_KEY changed to %q", got)
+	}
+}
@@ -0,0 +1,344 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/edhuardotierrez/gommit/internal/globals"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// ModelsTTL is how long a discovered model list is reused before the provider is asked again
+const ModelsTTL = 24 * time.Hour
+
+// modelsTimeout bounds a model listing request, so an unreachable provider falls back quickly
+const modelsTimeout = 10 * time.Second
+
+// ModelSource tells where a model list came from
+type ModelSource string
+
+const (
+	ModelsLive   ModelSource = "live"
+	ModelsCached ModelSource = "cached"
+	ModelsStatic ModelSource = "built-in"
+)
+
+// ModelList is the outcome of a model discovery. Err explains why the built-in list was used
+// instead of the provider's; the list itself is always usable.
+type ModelList struct {
+	Provider  types.ProviderName `json:"provider"`
+	Models    []string           `json:"models"`
+	FetchedAt time.Time          `json:"fetched_at"`
+	Source    ModelSource        `json:"-"`
+	Err       error              `json:"-"`
+}
+
+// defaultBaseURLs are the provider API roots; ProviderConfig.URI overrides them
+var defaultBaseURLs = map[types.ProviderName]string{
+	types.ProviderOpenAI:    "https://api.openai.com",
+	types.ProviderAnthropic: "https://api.anthropic.com",
+	types.ProviderGoogle:    "https://generativelanguage.googleapis.com",
+	types.ProviderOllama:    "http://localhost:11434",
+}
+
+// staticModels is the offline fallback when a provider cannot be queried
+var staticModels = map[types.ProviderName][]string{
+	types.ProviderOpenAI: {
+		"gpt-5-nano",
+		"gpt-5-mini",
+		"gpt-5",
+		"gpt-4o-mini",
+		"gpt-4o",
+		"gpt-4.1-nano",
+		"gpt-4.1-mini",
+	},
+	types.ProviderAnthropic: {
+		"claude-sonnet-4-0",
+		"claude-opus-4-1",
+		"claude-3-7-sonnet-latest",
+		"claude-3-5-haiku-latest",
+	},
+	types.ProviderOllama: {
+		"llama3",
+		"mistral",
+	},
+	types.ProviderGoogle: {
+		"gemini-2.5-flash-lite",
+		"gemini-2.5-flash",
+		"gemini-2.5-pro",
+	},
+}
+
+// GetAvailableModels returns the built-in list of models for a given provider
+func GetAvailableModels(provider types.ProviderName) []string {
+	return slices.Clone(staticModels[provider])
+}
+
+// BaseURL returns the API root used for a provider: the configured URI, or the provider's default.
+// The OpenAI and Anthropic clients add the /v1 version prefix themselves, so a URI ending in /v1
+// (as gateways often document it) is cut back to the root.
+func BaseURL(provider types.ProviderName, pc types.ProviderConfig) string {
+	if pc.URI != "" {
+		uri := strings.TrimRight(pc.URI, "/")
+		if provider == types.ProviderOpenAI || provider == types.ProviderAnthropic {
+			uri = strings.TrimSuffix(uri, "/v1")
+		}
+		return uri
+	}
+	return defaultBaseURLs[provider]
+}
+
+// Endpoint returns the API root the generation client of a provider sends to. It is BaseURL, which
+// newClient passes to every client so neither the SDK defaults nor variables such as OPENAI_BASE_URL
+// can redirect the requests. The Google client cannot be pointed elsewhere, so a uri is refused for it.
+func Endpoint(provider types.ProviderName, pc types.ProviderConfig) (string, error) {
+	if provider == types.ProviderGoogle && pc.URI != "" {
+		return "", fmt.Errorf("uri is not supported for the %s provider: its client always sends to %s", provider, defaultBaseURLs[provider])
+	}
+	return BaseURL(provider, pc), nil
+}
+
+// ListModels returns the models a provider offers to the configured credentials. Lists are cached
+// for ModelsTTL (refresh skips the cache); when the provider cannot be reached the built-in list is
+// returned with Err set.
+func ListModels(provider types.ProviderName, pc types.ProviderConfig, refresh bool) ModelList {
+	path := modelsCachePath(provider, pc)
+	if !refresh {
+		if list, ok := readModelsCache(path); ok {
+			list.Source = ModelsCached
+			return list
+		}
+	}
+
+	if meta, ok := ProviderByTitle(string(provider)); ok && slices.Contains(meta.Required, "api_key") && pc.APIKey == "" {
+		return ModelList{Provider: provider, Models: GetAvailableModels(provider), Source: ModelsStatic, Err: fmt.Errorf("no api_key configured")}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), modelsTimeout)
+	defer cancel()
+
+	models, err := fetchModels(ctx, provider, pc)
+	if err == nil && len(models) == 0 {
+		err = fmt.Errorf("%s returned no models", provider)
+	}
+	if err != nil {
+		return ModelList{Provider: provider, Models: GetAvailableModels(provider), Source: ModelsStatic, Err: err}
+	}
+
+	list := ModelList{Provider: provider, Models: models, FetchedAt: time.Now()}
+	writeModelsCache(path, list)
+	list.Source = ModelsLive
+	return list
+}
+
+// fetchModels queries the provider's model listing endpoint
+func fetchModels(ctx context.Context, provider types.ProviderName, pc types.ProviderConfig) ([]string, error) {
+	base := BaseURL(provider, pc)
+	header := http.Header{}
+
+	switch provider {
+	case types.ProviderOpenAI:
+		header.Set("Authorization", "Bearer "+pc.APIKey)
+		var body struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		if err := getJSON(ctx, base+"/v1/models", header, &body); err != nil {
+			return nil, err
+		}
+		var models []string
+		for _, m := range body.Data {
+			if isOpenAIChatModel(m.ID) {
+				models = append(models, m.ID)
+			}
+		}
+		sort.Strings(models)
+		return models, nil
+
+	case types.ProviderAnthropic:
+		header.Set("X-Api-Key", pc.APIKey)
+		header.Set("Anthropic-Version", "2023-06-01")
+		var body struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		if err := getJSON(ctx, base+"/v1/models?limit=1000", header, &body); err != nil {
+			return nil, err
+		}
+		// The API lists the newest models first, which is the order worth keeping
+		var models []string
+		for _, m := range body.Data {
+			models = append(models, m.ID)
+		}
+		return models, nil
+
+	case types.ProviderGoogle:
+		header.Set("X-Goog-Api-Key", pc.APIKey)
+		var body struct {
+			Models []struct {
+				Name    string   `json:"name"`
+				Methods []string `json:"supportedGenerationMethods"`
+			} `json:"models"`
+		}
+		if err := getJSON(ctx, base+"/v1beta/models?pageSize=1000", header, &body); err != nil {
+			return nil, err
+		}
+		var models []string
+		for _, m := range body.Models {
+			if slices.Contains(m.Methods, "generateContent") {
+				models = append(models, strings.TrimPrefix(m.Name, "models/"))
+			}
+		}
+		return models, nil
+
+	case types.ProviderOllama:
+		if pc.APIKey != "" {
+			header.Set("Authorization", "Bearer "+pc.APIKey)
+		}
+		var body struct {
+			Models []struct {
+				Name string `json:"name"`
+			} `json:"models"`
+		}
+		if err := getJSON(ctx, base+"/api/tags", header, &body); err != nil {
+			return nil, err
+		}
+		var models []string
+		for _, m := range body.Models {
+			models = append(models, m.Name)
+		}
+		sort.Strings(models)
+		return models, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported LLM provider: %s", provider)
+	}
+}
+
+// StatusError is a provider API response with an unexpected status code
+type StatusError struct {
+	URL    string
+	Status string
+	Code   int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("GET %s: %s", e.URL, e.Status)
+}
+
+// getJSON fetches url with the shared provider http.Client and decodes the JSON response into v
+func getJSON(ctx context.Context, url string, header http.Header, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header = header
+
+	resp, err := newHTTPClient(globals.VerboseMode).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{URL: req.URL.Redacted(), Status: resp.Status, Code: resp.StatusCode}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("could not parse model list: %w", err)
+	}
+	return nil
+}
+
+// isOpenAIChatModel filters the OpenAI model list (which includes embeddings, audio and image
+// models) down to the ones usable for chat completions
+func isOpenAIChatModel(id string) bool {
+	if !strings.HasPrefix(id, "gpt-") && !strings.HasPrefix(id, "chatgpt-") &&
+		!strings.HasPrefix(id, "o1") && !strings.HasPrefix(id, "o3") && !strings.HasPrefix(id, "o4") {
+		return false
+	}
+	for _, skip := range []string{"audio", "realtime", "tts", "transcribe", "search", "image", "instruct"} {
+		if strings.Contains(id, skip) {
+			return false
+		}
+	}
+	return true
+}
+
+// modelsCachePath returns the cache file for a provider, endpoint and key; the key is hashed so
+// accounts with different model access do not share a list, and it never lands on disk
+func modelsCachePath(provider types.ProviderName, pc types.ProviderConfig) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(string(provider) + "\x00" + BaseURL(provider, pc) + "\x00" + pc.APIKey))
+	return filepath.Join(dir, "gommit", "models", fmt.Sprintf("%s-%s.json", provider, hex.EncodeToString(sum[:8])))
+}
+
+func readModelsCache(path string) (ModelList, bool) {
+	var list ModelList
+	if path == "" {
+		return list, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &list) != nil {
+		return list, false
+	}
+	if len(list.Models) == 0 || time.Since(list.FetchedAt) > ModelsTTL {
+		return list, false
+	}
+	return list, true
+}
+
+// writeModelsCache stores a model list; failures only cost a request next time
+func writeModelsCache(path string, list ModelList) {
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0o600)
+}
+
+// ProviderByTitle returns the supported provider with the given title (e.g. "openai")
+func ProviderByTitle(title string) (types.ProviderTypes, bool) {
+	for _, p := range Providers {
+		if p.Title == title {
+			return p, true
+		}
+	}
+	return types.ProviderTypes{}, false
+}
+
+// WithEnvCredentials fills the api_key and uri a provider config leaves empty from the provider's
+// environment variables (e.g. OPENAI_API_KEY), so models can be listed before gommit is configured
+func WithEnvCredentials(provider types.ProviderName, pc types.ProviderConfig) types.ProviderConfig {
+	p, ok := ProviderByTitle(string(provider))
+	if !ok {
+		return pc
+	}
+	if pc.APIKey == "" && p.ConfigVars["api_key"] != "" {
+		pc.APIKey = os.Getenv(p.ConfigVars["api_key"])
+	}
+	if pc.URI == "" && p.ConfigVars["uri"] != "" {
+		pc.URI = os.Getenv(p.ConfigVars["uri"])
+	}
+	return pc
+}
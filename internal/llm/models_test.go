@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// TestListModels_Providers serves each provider's model listing and checks how it is parsed and filtered.
+func TestListModels_Providers(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	cases := []struct {
+		provider types.ProviderName
+		path     string
+		header   string
+		body     string
+		want     []string
+	}{
+		{
+			provider: types.ProviderOpenAI,
+			path:     "/v1/models",
+			header:   "Authorization",
+			body:     `{"data":[{"id":"gpt-4o-mini"},{"id":"text-embedding-3-small"},{"id":"gpt-4o-realtime-preview"},{"id":"gpt-4.1"}]}`,
+			want:     []string{"gpt-4.1", "gpt-4o-mini"},
+		},
+		{
+			provider: types.ProviderAnthropic,
+			path:     "/v1/models",
+			header:   "X-Api-Key",
+			body:     `{"data":[{"id":"claude-sonnet-4-20250514"},{"id":"claude-3-5-haiku-20241022"}]}`,
+			want:     []string{"claude-sonnet-4-20250514", "claude-3-5-haiku-20241022"},
+		},
+		{
+			provider: types.ProviderGoogle,
+			path:     "/v1beta/models",
+			header:   "X-Goog-Api-Key",
+			body:     `{"models":[{"name":"models/gemini-2.5-flash","supportedGenerationMethods":["generateContent"]},{"name":"models/text-embedding-004","supportedGenerationMethods":["embedContent"]}]}`,
+			want:     []string{"gemini-2.5-flash"},
+		},
+		{
+			provider: types.ProviderOllama,
+			path:     "/api/tags",
+			body:     `{"models":[{"name":"qwen2.5-coder:7b"},{"name":"llama3.1:latest"}]}`,
+			want:     []string{"llama3.1:latest", "qwen2.5-coder:7b"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.provider), func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if r.URL.Path != tc.path {
+					http.NotFound(w, r)
+					return
+				}
+				if tc.header != "" && r.Header.Get(tc.header) == "" {
+					http.Error(w, "missing credentials", http.StatusUnauthorized)
+					return
+				}
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			pc := types.ProviderConfig{APIKey: "test-key", URI: server.URL}
+			list := ListModels(tc.provider, pc, false)
+			if list.Err != nil || list.Source != ModelsLive {
+				t.Fatalf("ListModels() source = %s, err = %v", list.Source, list.Err)
+			}
+			if !slices.Equal(list.Models, tc.want) {
+				t.Fatalf("ListModels() = %q, want %q", list.Models, tc.want)
+			}
+
+			// The second call is answered from the cache
+			if list := ListModels(tc.provider, pc, false); list.Source != ModelsCached || requests != 1 {
+				t.Fatalf("ListModels() source = %s after %d requests, want a cached list", list.Source, requests)
+			}
+		})
+	}
+}
+
+// TestListModels_Fallback checks that an unreachable or failing provider yields the built-in list.
+func TestListModels_Fallback(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	list := ListModels(types.ProviderAnthropic, types.ProviderConfig{APIKey: "bad", URI: server.URL}, false)
+	if list.Err == nil || list.Source != ModelsStatic {
+		t.Fatalf("ListModels() source = %s, err = %v, want the built-in list", list.Source, list.Err)
+	}
+	if !slices.Equal(list.Models, GetAvailableModels(types.ProviderAnthropic)) {
+		t.Fatalf("ListModels() = %q, want the built-in list", list.Models)
+	}
+
+	// Failures are not cached
+	if list := ListModels(types.ProviderAnthropic, types.ProviderConfig{APIKey: "bad", URI: server.URL}, false); list.Source != ModelsStatic {
+		t.Fatalf("ListModels() source = %s, want a new attempt", list.Source)
+	}
+}
@@ -0,0 +1,137 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+const (
+	// DefaultConcurrency bounds the parallel provider calls of the worker pool
+	DefaultConcurrency = 4
+
+	// rateLimitRetries is how many times a rate-limited call is retried
+	rateLimitRetries = 4
+)
+
+// rateLimitBackoff is the first wait after a rate-limit error; it doubles on every retry
+var rateLimitBackoff = 2 * time.Second
+
+// rateLimitMarkers identify rate-limit and overload errors across providers
+var rateLimitMarkers = []string{"429", "rate limit", "rate_limit", "too many requests", "overloaded", "529", "resource_exhausted", "quota"}
+
+// job is one prompt for the pool; results keep the order of the jobs
+type job struct {
+	Prompt      string
+	CallOptions []llms.CallOption
+}
+
+// jobResult is the outcome of a job
+type jobResult struct {
+	Generation *generation
+	Err        error
+}
+
+// pool runs prompts against a model with bounded concurrency. When a call is rate limited every worker
+// pauses until the backoff has passed, so a burst of parallel calls slows down instead of failing.
+type pool struct {
+	client      llms.Model
+	concurrency int
+
+	mu     sync.Mutex
+	paused time.Time // no call starts before this instant
+}
+
+func newPool(client llms.Model, concurrency int) *pool {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	return &pool{client: client, concurrency: concurrency}
+}
+
+// Run executes the jobs and returns their results in order
+func (p *pool) Run(ctx context.Context, jobs []job) []jobResult {
+	results := make([]jobResult, len(jobs))
+	queue := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(p.concurrency, len(jobs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				results[i] = p.do(ctx, jobs[i])
+			}
+		}()
+	}
+
+	for i := range jobs {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	return results
+}
+
+// do runs one job, retrying rate-limited calls with exponential backoff and jitter
+func (p *pool) do(ctx context.Context, j job) jobResult {
+	backoff := rateLimitBackoff
+	for attempt := 0; ; attempt++ {
+		if err := p.wait(ctx); err != nil {
+			return jobResult{Err: err}
+		}
+
+		gen, err := generate(p.client, j.Prompt, j.CallOptions)
+		if err == nil || !isRateLimited(err) || attempt == rateLimitRetries {
+			return jobResult{Generation: gen, Err: err}
+		}
+
+		jitter := time.Duration(rand.Int63n(int64(backoff)/2 + 1))
+		p.pause(backoff + jitter)
+		backoff *= 2
+	}
+}
+
+// wait blocks until the pool is no longer paused
+func (p *pool) wait(ctx context.Context) error {
+	for {
+		p.mu.Lock()
+		delay := time.Until(p.paused)
+		p.mu.Unlock()
+		if delay <= 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// pause stops new calls for d, extending any pause in progress
+func (p *pool) pause(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if until := time.Now().Add(d); until.After(p.paused) {
+		p.paused = until
+	}
+}
+
+func isRateLimited(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range rateLimitMarkers {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/edhuardotierrez/gommit/internal/git"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// fakeModel answers every prompt after a short delay, failing the first rateLimited calls with a 429
+type fakeModel struct {
+	rateLimited int32
+	calls       atomic.Int32
+	active      atomic.Int32
+	maxActive   atomic.Int32
+}
+
+func (m *fakeModel) GenerateContent(_ context.Context, messages []llms.MessageContent, _ ...llms.CallOption) (*llms.ContentResponse, error) {
+	n := m.calls.Add(1)
+	active := m.active.Add(1)
+	defer m.active.Add(-1)
+	for {
+		peak := m.maxActive.Load()
+		if active <= peak || m.maxActive.CompareAndSwap(peak, active) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if n <= m.rateLimited {
+		return nil, errors.New("API returned unexpected status code: 429: Rate limit reached")
+	}
+
+	prompt := messages[0].Parts[0].(llms.TextContent).Text
+	files := strings.Count(prompt, "File: ")
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{
+		Content:        fmt.Sprintf("summary of %d files", files),
+		GenerationInfo: map[string]any{"PromptTokens": 100, "CompletionTokens": 10},
+	}}}, nil
+}
+
+func (m *fakeModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// TestPool_BoundedAndRateLimitAware checks that the pool never exceeds its concurrency and retries 429s.
+func TestPool_BoundedAndRateLimitAware(t *testing.T) {
+	defer func(d time.Duration) { rateLimitBackoff = d }(rateLimitBackoff)
+	rateLimitBackoff = 10 * time.Millisecond
+
+	model := &fakeModel{rateLimited: 3}
+	jobs := make([]job, 12)
+	for i := range jobs {
+		jobs[i] = job{Prompt: fmt.Sprintf("File: %d", i)}
+	}
+
+	results := newPool(model, 3).Run(context.Background(), jobs)
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("job %d failed: %v", i, r.Err)
+		}
+	}
+	if peak := model.maxActive.Load(); peak > 3 {
+		t.Fatalf("concurrency peaked at %d, want at most 3", peak)
+	}
+	if calls := model.calls.Load(); calls != 15 {
+		t.Fatalf("calls = %d, want 15 (12 jobs + 3 rate-limited retries)", calls)
+	}
+}
+
+// TestSummarizeChanges checks the threshold and that the map stage summarizes every group of files.
+func TestSummarizeChanges(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	changes := make([]git.StagedChange, 60)
+	for i := range changes {
+		changes[i] = git.StagedChange{Path: fmt.Sprintf("pkg/file%02d.go", i), Status: "M", Diff: strings.Repeat("+x\n", 100)}
+	}
+
+	cfg := &types.Config{Summarize: types.SummarizeConfig{ChunkChars: 1000, Concurrency: 2}}
+	if !needsSummary(cfg.Summarize, changes) {
+		t.Fatalf("60 files should trigger the summary mode")
+	}
+	if needsSummary(cfg.Summarize, changes[:5]) {
+		t.Fatalf("5 small files should not trigger the summary mode")
+	}
+
+	chunks := chunkChanges(changes, 1000)
+	if len(chunks) != 20 {
+		t.Fatalf("chunkChanges() returned %d chunks, want 20", len(chunks))
+	}
+
+	result := &Result{}
+	text, err := summarizeChanges(cfg, &fakeModel{}, types.ProviderOpenAI, "https://api.openai.com", "gpt-4o", changes, result)
+	if err != nil {
+		t.Fatalf("summarizeChanges failed: %v", err)
+	}
+	if got := strings.Count(text, "summary of 3 files"); got != 20 {
+		t.Fatalf("expected 20 group summaries, got %d in:\n%s", got, text)
+	}
+	if result.PromptTokens != 2000 {
+		t.Fatalf("PromptTokens = %d, want 2000", result.PromptTokens)
+	}
+}
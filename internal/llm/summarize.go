@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/edhuardotierrez/gommit/internal/audit"
+	"github.com/edhuardotierrez/gommit/internal/colors"
+	"github.com/edhuardotierrez/gommit/internal/git"
+	"github.com/edhuardotierrez/gommit/internal/globals"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+const (
+	DefaultSummarizeMinFiles     = 50
+	DefaultSummarizeMinDiffChars = 100000
+	DefaultSummarizeChunkChars   = 12000
+
+	summarizePrompt = `Summarize the following git changes for someone who will write the commit message.
+For each file give one short line: what changed and, when the diff shows it, why. Group closely related files.
+Do not write a commit message, do not use code blocks, and do not invent changes that are not in the diff.`
+)
+
+// summaryModels are the cheap models used for the per-group summaries when none is configured.
+// Ollama runs locally, so it keeps the main model.
+var summaryModels = map[types.ProviderName]string{
+	types.ProviderOpenAI:    "gpt-4o-mini",
+	types.ProviderAnthropic: "claude-3-5-haiku-latest",
+	types.ProviderGoogle:    "gemini-2.5-flash-lite",
+}
+
+// needsSummary reports whether the changeset is large enough for the two-stage mode
+func needsSummary(cfg types.SummarizeConfig, changes []git.StagedChange) bool {
+	if cfg.Disabled {
+		return false
+	}
+	minFiles, minChars := cfg.MinFiles, cfg.MinDiffChars
+	if minFiles <= 0 {
+		minFiles = DefaultSummarizeMinFiles
+	}
+	if minChars <= 0 {
+		minChars = DefaultSummarizeMinDiffChars
+	}
+
+	size := 0
+	for _, c := range changes {
+		size += len(c.Diff)
+	}
+	return len(changes) >= minFiles || size >= minChars
+}
+
+// chunkChanges packs files into groups of at most chunkChars diff characters, keeping their order.
+// A diff larger than a whole group is cut to fit.
+func chunkChanges(changes []git.StagedChange, chunkChars int) [][]git.StagedChange {
+	var chunks [][]git.StagedChange
+	var current []git.StagedChange
+	size := 0
+
+	for _, c := range changes {
+		if len(c.Diff) > chunkChars {
+			c.Diff = c.Diff[:chunkChars] + "\n...[truncated]..."
+		}
+		if len(current) > 0 && size+len(c.Diff) > chunkChars {
+			chunks = append(chunks, current)
+			current, size = nil, 0
+		}
+		current = append(current, c)
+		size += len(c.Diff)
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// summarizeChanges is the map stage: every group of files is summarized in parallel with a cheap model.
+// It returns the text that replaces the diffs in the final prompt. Groups whose summary fails are
+// listed by file name only; the stage fails when no group could be summarized. endpoint is where
+// client sends, for the audit log.
+func summarizeChanges(cfg *types.Config, client llms.Model, provider types.ProviderName, endpoint, mainModel string, changes []git.StagedChange, result *Result) (string, error) {
+	model := cfg.Summarize.Model
+	if model == "" {
+		model = summaryModels[provider]
+	}
+	if model == "" {
+		model = mainModel
+	}
+	chunkChars := cfg.Summarize.ChunkChars
+	if chunkChars <= 0 {
+		chunkChars = DefaultSummarizeChunkChars
+	}
+
+	chunks := chunkChanges(changes, chunkChars)
+	if globals.VerboseMode {
+		colors.InfoOutput("\n\n----------------------- Summarizing %d files in %d groups with %s\n", len(changes), len(chunks), model)
+	}
+
+	var callOptions []llms.CallOption
+	if model != "" {
+		callOptions = append(callOptions, llms.WithModel(model))
+	}
+
+	jobs := make([]job, len(chunks))
+	for i, chunk := range chunks {
+		var b strings.Builder
+		b.WriteString(summarizePrompt + "\n\n")
+		for _, c := range chunk {
+			fmt.Fprintf(&b, "File: %s (Status: %s)\nDiff:\n%s\n\n", c.Path, c.Status, c.Diff)
+		}
+		jobs[i] = job{Prompt: compressPrompt(b.String()), CallOptions: callOptions}
+		if err := auditPrompt(cfg, audit.KindSummary, string(provider), endpoint, model, jobs[i].Prompt); err != nil {
+			return "", err
+		}
+	}
+
+	results := newPool(client, cfg.Summarize.Concurrency).Run(context.Background(), jobs)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "The changeset is too large to show in full (%d files). Summaries of the changes by group of files:\n\n", len(changes))
+	failed := 0
+	var lastErr error
+	for i, r := range results {
+		if r.Err != nil {
+			failed++
+			lastErr = r.Err
+			for _, c := range chunks[i] {
+				fmt.Fprintf(&out, "File: %s (Status: %s, no summary available)\n", c.Path, c.Status)
+			}
+			out.WriteString("\n")
+			continue
+		}
+		recordUsage(cfg, result, string(provider), model, r.Generation)
+		out.WriteString(strings.TrimSpace(r.Generation.Text) + "\n\n")
+	}
+
+	if failed == len(results) {
+		return "", fmt.Errorf("error summarizing the changes: %w", lastErr)
+	}
+	if failed > 0 {
+		colors.WarningOutput("⚠️ %d of %d groups of files could not be summarized: %v\n", failed, len(results), lastErr)
+	}
+	return out.String(), nil
+}
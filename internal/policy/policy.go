@@ -0,0 +1,133 @@
+// Package policy decides which providers and endpoints the changes of a repository may be sent to
+package policy
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// Policy is a policy that applies to the current repository, with where it was set
+type Policy struct {
+	types.PolicyConfig
+	Source string // e.g. "the config (remote github.com/acme/*)" or the path of .gommitrules
+}
+
+// DeniedError is returned when a policy refuses a provider or endpoint
+type DeniedError struct {
+	Provider string
+	Endpoint string
+	Reason   string
+	Source   string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("sending changes to %s (%s) is not allowed for this repository: %s by the policy in %s", e.Provider, e.Endpoint, e.Reason, e.Source)
+}
+
+// Applicable returns the configured policies whose remote pattern matches the origin URL (those
+// without one apply to every repository), followed by the repository's own policy when it has one
+func Applicable(configured []types.PolicyConfig, repo *types.PolicyConfig, repoSource, origin string) []Policy {
+	var policies []Policy
+	for _, p := range configured {
+		switch {
+		case p.Remote == "":
+			policies = append(policies, Policy{PolicyConfig: p, Source: "the config"})
+		case origin != "" && MatchRemote(p.Remote, origin):
+			policies = append(policies, Policy{PolicyConfig: p, Source: fmt.Sprintf("the config (remote %s)", p.Remote)})
+		}
+	}
+	if repo != nil {
+		policies = append(policies, Policy{PolicyConfig: *repo, Source: repoSource})
+	}
+	return policies
+}
+
+// Check returns a *DeniedError when any of the policies refuses sending to provider at endpoint:
+// a deny rule matches, or the policy has allow rules and none of them matches
+func Check(policies []Policy, provider, endpoint string) error {
+	for _, p := range policies {
+		for _, rule := range p.Deny {
+			if matchRule(rule, provider, endpoint) {
+				return &DeniedError{Provider: provider, Endpoint: endpoint, Reason: "denied " + describe(rule), Source: p.Source}
+			}
+		}
+		if len(p.Allow) == 0 {
+			continue
+		}
+		allowed := false
+		for _, rule := range p.Allow {
+			if matchRule(rule, provider, endpoint) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			names := make([]string, 0, len(p.Allow))
+			for _, rule := range p.Allow {
+				names = append(names, describe(rule))
+			}
+			return &DeniedError{Provider: provider, Endpoint: endpoint, Reason: "only " + strings.Join(names, ", ") + " allowed", Source: p.Source}
+		}
+	}
+	return nil
+}
+
+// MatchRemote matches a glob against a remote URL as written and in its host/path form, so
+// "github.com/acme/*" matches both git@github.com:acme/app.git and https://github.com/acme/app
+func MatchRemote(pattern, remote string) bool {
+	return match(pattern, remote) || match(pattern, normalizeRemote(remote))
+}
+
+// normalizeRemote turns ssh, scp-like and http(s) remote URLs into host/path without ".git"
+func normalizeRemote(remote string) string {
+	s := remote
+	if u, err := url.Parse(s); err == nil && u.Host != "" {
+		s = u.Hostname() + u.Path
+	} else if at := strings.Index(s, "@"); at >= 0 && strings.Contains(s[at:], ":") {
+		// scp-like syntax: user@host:path
+		host, path, _ := strings.Cut(s[at+1:], ":")
+		s = host + "/" + strings.TrimPrefix(path, "/")
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(s, "/"), ".git")
+}
+
+func matchRule(rule types.PolicyRule, provider, endpoint string) bool {
+	if rule.Provider != "" && rule.Provider != provider {
+		return false
+	}
+	if rule.Endpoint == "" {
+		return true
+	}
+	if strings.Contains(rule.Endpoint, "://") {
+		return match(rule.Endpoint, strings.TrimRight(endpoint, "/"))
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	return match(rule.Endpoint, u.Host) || match(rule.Endpoint, u.Hostname())
+}
+
+func describe(rule types.PolicyRule) string {
+	switch {
+	case rule.Endpoint == "":
+		return rule.Provider
+	case rule.Provider == "":
+		return rule.Endpoint
+	}
+	return rule.Provider + " at " + rule.Endpoint
+}
+
+// match reports whether s matches a glob in which * matches any run of characters, slashes
+// included, and ? a single character; it ignores case
+func match(pattern, s string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	re, err := regexp.Compile("(?i)^" + expr + "$")
+	return err == nil && re.MatchString(s)
+}
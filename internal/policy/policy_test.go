@@ -0,0 +1,81 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// TestCheck checks allow and deny rules against providers and endpoints.
+func TestCheck(t *testing.T) {
+	localOllama := Policy{Source: ".gommitrules", PolicyConfig: types.PolicyConfig{
+		Allow: []types.PolicyRule{{Provider: "ollama", Endpoint: "localhost"}, {Provider: "ollama", Endpoint: "http://127.0.0.1:*"}},
+	}}
+	noOpenAI := Policy{Source: "the config", PolicyConfig: types.PolicyConfig{
+		Deny: []types.PolicyRule{{Provider: "openai"}, {Endpoint: "*.example.com"}},
+	}}
+
+	for _, tc := range []struct {
+		policies []Policy
+		provider string
+		endpoint string
+		allowed  bool
+	}{
+		{nil, "openai", "https://api.openai.com", true},
+		{[]Policy{localOllama}, "ollama", "http://localhost:11434", true},
+		{[]Policy{localOllama}, "ollama", "http://127.0.0.1:11434/", true},
+		{[]Policy{localOllama}, "ollama", "https://ollama.internal", false},
+		{[]Policy{localOllama}, "openai", "https://api.openai.com", false},
+		{[]Policy{noOpenAI}, "openai", "https://proxy.internal", false},
+		{[]Policy{noOpenAI}, "anthropic", "https://api.anthropic.com", true},
+		{[]Policy{noOpenAI}, "ollama", "https://gpu.example.com", false},
+		{[]Policy{noOpenAI, localOllama}, "anthropic", "https://api.anthropic.com", false},
+	} {
+		err := Check(tc.policies, tc.provider, tc.endpoint)
+		var denied *DeniedError
+		if tc.allowed && err != nil || !tc.allowed && !errors.As(err, &denied) {
+			t.Errorf("Check(%s, %s) = %v, want allowed=%v", tc.provider, tc.endpoint, err, tc.allowed)
+		}
+	}
+}
+
+// TestApplicable checks which configured policies apply to a repository's origin.
+func TestApplicable(t *testing.T) {
+	configured := []types.PolicyConfig{
+		{Remote: "github.com/acme/secret-*"},
+		{Remote: "gitlab.example.com/*"},
+		{},
+	}
+	repo := &types.PolicyConfig{Deny: []types.PolicyRule{{Provider: "openai"}}}
+
+	for _, tc := range []struct {
+		origin string
+		want   []string
+	}{
+		{"git@github.com:acme/secret-app.git", []string{"github.com/acme/secret-*", "", "rules"}},
+		{"https://github.com/acme/secret-app", []string{"github.com/acme/secret-*", "", "rules"}},
+		{"ssh://git@gitlab.example.com:2222/team/app.git", []string{"gitlab.example.com/*", "", "rules"}},
+		{"https://github.com/acme/public", []string{"", "rules"}},
+		{"", []string{"", "rules"}},
+	} {
+		var got []string
+		for _, p := range Applicable(configured, repo, "rules", tc.origin) {
+			if p.Source == "rules" {
+				got = append(got, "rules")
+			} else {
+				got = append(got, p.Remote)
+			}
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("Applicable(%q) = %q, want %q", tc.origin, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("Applicable(%q) = %q, want %q", tc.origin, got, tc.want)
+				break
+			}
+		}
+	}
+}
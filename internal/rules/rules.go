@@ -0,0 +1,276 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/edhuardotierrez/gommit/internal/git"
+	"github.com/edhuardotierrez/gommit/internal/lint"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// FileNames are the accepted names for the per-repository rules file, in lookup order
+var FileNames = []string{".gommitrules", ".gommitrules.yaml", ".gommitrules.yml"}
+
+// knownKeys are the top-level keys that mark a rules file as structured YAML rather than free text
+var knownKeys = []string{"types", "scopes", "subject_template", "ticket", "examples", "instructions", "history", "policy"}
+
+// Scope maps a conventional-commit scope to the paths it covers
+type Scope struct {
+	Name        string   `yaml:"name"`
+	Paths       []string `yaml:"paths,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+}
+
+// Ticket describes the issue reference every message must carry
+type Ticket struct {
+	Required bool   `yaml:"required"`
+	Pattern  string `yaml:"pattern,omitempty"`
+}
+
+// Rules is the parsed content of a .gommitrules file
+type Rules struct {
+	Path            string   `yaml:"-"`
+	Structured      bool     `yaml:"-"`
+	Text            string   `yaml:"-"` // free-text prompt for unstructured files
+	Types           []string `yaml:"types,omitempty"`
+	Scopes          []Scope  `yaml:"scopes,omitempty"`
+	SubjectTemplate string   `yaml:"subject_template,omitempty"`
+	Ticket          Ticket   `yaml:"ticket,omitempty"`
+	Examples        []string `yaml:"examples,omitempty"`
+	Instructions    string   `yaml:"instructions,omitempty"`
+
+	// History overrides the user's history settings for this repository
+	History *types.HistoryConfig `yaml:"history,omitempty"`
+
+	// Policy restricts the providers and endpoints this repository's changes may be sent to, on top
+	// of the policies in the configuration
+	Policy *types.PolicyConfig `yaml:"policy,omitempty"`
+
+	subjectPattern *regexp.Regexp
+	ticketPattern  *regexp.Regexp
+}
+
+// Find returns the path of the rules file nearest to the working directory, searching up to the
+// repository root (so a monorepo package can override the root rules). Returns "" when none exists.
+func Find() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	root := git.GetRootPath()
+
+	for {
+		for _, name := range FileNames {
+			candidate := filepath.Join(dir, name)
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if root == "" || dir == root || parent == dir || !strings.HasPrefix(parent, root) {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load reads the rules file for the current repository. It returns nil (and no error) when there is none.
+func Load() (*Rules, error) {
+	path := Find()
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	if len(strings.TrimSpace(string(content))) == 0 {
+		return nil, nil
+	}
+
+	r, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r.Path = path
+	return r, nil
+}
+
+// Parse reads structured YAML rules, falling back to the free-text format when the
+// content does not look like a rules document
+func Parse(content []byte) (*Rules, error) {
+	if !isStructured(content) {
+		return &Rules{Text: string(content)}, nil
+	}
+
+	r := &Rules{Structured: true}
+	if err := yaml.Unmarshal(content, r); err != nil {
+		return nil, fmt.Errorf("could not parse rules: %w", err)
+	}
+
+	if r.Ticket.Required && r.Ticket.Pattern == "" {
+		r.Ticket.Pattern = lint.DefaultTicketPattern
+	}
+	if r.Ticket.Pattern != "" {
+		pattern, err := regexp.Compile(r.Ticket.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ticket pattern: %w", err)
+		}
+		r.ticketPattern = pattern
+	}
+
+	if r.SubjectTemplate != "" {
+		r.subjectPattern = compileTemplate(r.SubjectTemplate, r.Ticket.Pattern)
+	}
+
+	for i, s := range r.Scopes {
+		if s.Name == "" {
+			return nil, fmt.Errorf("scope #%d has no name", i+1)
+		}
+	}
+
+	return r, nil
+}
+
+// isStructured reports whether the content is a YAML mapping using at least one known key
+func isStructured(content []byte) bool {
+	var doc map[string]any
+	if err := yaml.Unmarshal(content, &doc); err != nil || len(doc) == 0 {
+		return false
+	}
+	for _, k := range knownKeys {
+		if _, ok := doc[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// compileTemplate turns a subject template such as "{type}({scope}): {ticket} {description}" into a regexp
+func compileTemplate(template, ticketPattern string) *regexp.Regexp {
+	if ticketPattern == "" {
+		ticketPattern = lint.DefaultTicketPattern
+	}
+	placeholders := map[string]string{
+		"{type}":        `[a-z]+`,
+		"{scope}":       `[^()\s]+`,
+		"{ticket}":      `(?:` + ticketPattern + `)`,
+		"{description}": `\S.*`,
+	}
+
+	expr := regexp.QuoteMeta(template)
+	for name, sub := range placeholders {
+		expr = strings.ReplaceAll(expr, regexp.QuoteMeta(name), sub)
+	}
+	return regexp.MustCompile("^" + expr + "$")
+}
+
+// ScopeNames returns the declared scope names in order
+func (r *Rules) ScopeNames() []string {
+	names := make([]string, 0, len(r.Scopes))
+	for _, s := range r.Scopes {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// DropLocked removes the rules that would override a key of the user configuration locked by the
+// system or shared config (see types.Config.IsLocked), and returns their keys
+func (r *Rules) DropLocked(locked func(key string) bool) []string {
+	if r == nil || !r.Structured {
+		return nil
+	}
+	var dropped []string
+	if r.History != nil && locked("history") {
+		r.History = nil
+		dropped = append(dropped, "history")
+	}
+	if len(r.Types) > 0 && locked("lint.types") {
+		r.Types = nil
+		dropped = append(dropped, "lint.types")
+	}
+	if len(r.Scopes) > 0 && locked("lint.scopes") {
+		r.Scopes = nil
+		dropped = append(dropped, "lint.scopes")
+	}
+	if r.ticketPattern != nil && locked("ticket") {
+		r.Ticket, r.ticketPattern = Ticket{}, nil
+		dropped = append(dropped, "ticket")
+	}
+	return dropped
+}
+
+// ApplyLint narrows the lint options with the repository rules
+func (r *Rules) ApplyLint(opts *lint.Options) {
+	if r == nil || !r.Structured {
+		return
+	}
+	if len(r.Types) > 0 {
+		opts.Types = r.Types
+	}
+	if len(r.Scopes) > 0 {
+		opts.Scopes = r.ScopeNames()
+	}
+	if r.subjectPattern != nil {
+		opts.SubjectTemplate = r.SubjectTemplate
+		opts.SubjectPattern = r.subjectPattern
+	}
+	if r.ticketPattern != nil {
+		opts.TicketPattern = r.ticketPattern
+		opts.TicketRequired = r.Ticket.Required
+	}
+}
+
+// Prompt compiles the structured rules into instructions appended to the system prompt
+func (r *Rules) Prompt() string {
+	if r == nil || !r.Structured {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Project commit rules (these take precedence over the general rules above):\n")
+	if len(r.Types) > 0 {
+		fmt.Fprintf(&b, "- Allowed commit types: %s\n", strings.Join(r.Types, ", "))
+	}
+	if len(r.Scopes) > 0 {
+		b.WriteString("- Allowed scopes (pick the one matching the changed paths):\n")
+		for _, s := range r.Scopes {
+			line := "  - " + s.Name
+			if len(s.Paths) > 0 {
+				line += " (" + strings.Join(s.Paths, ", ") + ")"
+			}
+			if s.Description != "" {
+				line += ": " + s.Description
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	if r.SubjectTemplate != "" {
+		fmt.Fprintf(&b, "- The subject line must follow this template: %s\n", r.SubjectTemplate)
+	}
+	if r.Ticket.Required {
+		fmt.Fprintf(&b, "- The message must reference a ticket matching %s\n", r.Ticket.Pattern)
+	}
+	if strings.TrimSpace(r.Instructions) != "" {
+		fmt.Fprintf(&b, "- %s\n", strings.TrimSpace(r.Instructions))
+	}
+	if len(r.Examples) > 0 {
+		b.WriteString("Examples of good commit messages in this project:\n")
+		for _, e := range r.Examples {
+			fmt.Fprintf(&b, "---\n%s\n", strings.TrimSpace(e))
+		}
+		b.WriteString("---\n")
+	}
+	return b.String()
+}
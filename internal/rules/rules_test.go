@@ -0,0 +1,82 @@
+package rules
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/edhuardotierrez/gommit/internal/lint"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// TestParse_FreeTextStaysSupported ensures plain prose is not mistaken for structured rules.
+func TestParse_FreeTextStaysSupported(t *testing.T) {
+	r, err := Parse([]byte("Always write commit messages in English.\nNote: keep them short."))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if r.Structured || r.Text == "" {
+		t.Fatalf("expected free-text rules, got %+v", r)
+	}
+}
+
+// TestParse_StructuredRulesFeedLint checks that structured rules compile into lint options.
+func TestParse_StructuredRulesFeedLint(t *testing.T) {
+	content := `
+types: [feat, fix]
+scopes:
+  - name: api
+    paths: ["internal/api/**"]
+subject_template: "{type}({scope}): {ticket} {description}"
+ticket:
+  required: true
+examples:
+  - "feat(api): PROJ-12 add pagination"
+`
+	r, err := Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !r.Structured {
+		t.Fatal("expected structured rules")
+	}
+
+	opts := lint.OptionsFromConfig(&types.Config{}, "conventional", 500)
+	r.ApplyLint(&opts)
+	if !slices.Equal(opts.Scopes, []string{"api"}) || !slices.Equal(opts.Types, []string{"feat", "fix"}) {
+		t.Fatalf("unexpected options: types=%v scopes=%v", opts.Types, opts.Scopes)
+	}
+
+	if v := lint.Check("feat(api): PROJ-12 add pagination", opts); len(v) != 0 {
+		t.Fatalf("valid message rejected: %v", v)
+	}
+	if v := lint.Check("feat(api): add pagination", opts); len(v) == 0 {
+		t.Fatal("message without ticket accepted")
+	}
+}
+
+// TestDropLocked checks that rules overriding locked keys are dropped and the others kept.
+func TestDropLocked(t *testing.T) {
+	r, err := Parse([]byte("types: [feat, fix]\nscopes:\n  - name: api\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	cfg := &types.Config{Locked: []string{"lint.types"}}
+	if dropped := r.DropLocked(cfg.IsLocked); !slices.Equal(dropped, []string{"lint.types"}) {
+		t.Fatalf("dropped = %v", dropped)
+	}
+	if r.Types != nil || len(r.Scopes) != 1 {
+		t.Errorf("rules after DropLocked: types=%v scopes=%v", r.Types, r.Scopes)
+	}
+}
+
+// TestParse_Policy checks that a file with only a policy is read as structured rules.
+func TestParse_Policy(t *testing.T) {
+	r, err := Parse([]byte("policy:\n  allow:\n    - provider: ollama\n      endpoint: localhost\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := []types.PolicyRule{{Provider: "ollama", Endpoint: "localhost"}}
+	if !r.Structured || r.Policy == nil || !slices.Equal(r.Policy.Allow, want) {
+		t.Fatalf("unexpected rules: %+v", r)
+	}
+}
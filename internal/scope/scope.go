@@ -0,0 +1,274 @@
+package scope
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule maps a set of path globs (supporting `**`) to a scope
+type Rule struct {
+	Scope string
+	Paths []string
+}
+
+// containerDirs are top-level directories that only group packages; the fallback skips them
+// so that "internal/llm/llm.go" yields "llm" rather than "internal"
+var containerDirs = map[string]bool{
+	"internal": true, "pkg": true, "cmd": true, "src": true, "lib": true, "libs": true,
+	"apps": true, "packages": true, "crates": true, "services": true, "modules": true,
+}
+
+// Inferrer resolves the scope of changed paths
+type Inferrer struct {
+	Rules    []Rule   // explicit rules, first match wins
+	Members  []string // workspace member directories, relative to the repository root
+	Fallback bool     // fall back to the top-level package or directory
+}
+
+// New creates an Inferrer with the given rules and the workspace members detected under root
+func New(root string, rules []Rule, fallback bool) *Inferrer {
+	return &Inferrer{Rules: rules, Members: DetectWorkspace(root), Fallback: fallback}
+}
+
+// ScopeOf returns the scope for a single repository-relative path ("" when none applies)
+func (in *Inferrer) ScopeOf(p string) string {
+	p = filepath.ToSlash(p)
+
+	for _, r := range in.Rules {
+		for _, g := range r.Paths {
+			if Match(g, p) {
+				return r.Scope
+			}
+		}
+	}
+
+	// Longest workspace member containing the path
+	best := ""
+	for _, m := range in.Members {
+		if (p == m || strings.HasPrefix(p, m+"/")) && len(m) > len(best) {
+			best = m
+		}
+	}
+	if best != "" {
+		return path.Base(best)
+	}
+
+	if !in.Fallback {
+		return ""
+	}
+
+	parts := strings.Split(p, "/")
+	dirs := parts[:len(parts)-1]
+	for i, d := range dirs {
+		if containerDirs[d] && i+1 < len(dirs) {
+			continue
+		}
+		if strings.HasPrefix(d, ".") {
+			return ""
+		}
+		return d
+	}
+	return ""
+}
+
+// Infer returns the single scope shared by all changed paths that have one, or "" when
+// the paths disagree. Paths without a scope (e.g. root-level files) do not vote.
+func (in *Inferrer) Infer(paths []string) string {
+	found := ""
+	for _, p := range paths {
+		s := in.ScopeOf(p)
+		if s == "" {
+			continue
+		}
+		if found != "" && s != found {
+			return ""
+		}
+		found = s
+	}
+	return found
+}
+
+// Match reports whether a slash-separated path matches a glob where `**` spans directories
+func Match(pattern, name string) bool {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	// A bare directory pattern covers everything below it
+	if !strings.ContainsAny(pattern, "*?[") {
+		pattern = strings.TrimSuffix(pattern, "/")
+		return name == pattern || strings.HasPrefix(name, pattern+"/")
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// DetectWorkspace returns the member directories declared by go.work, package.json or
+// pnpm-workspace.yaml workspaces and Cargo workspaces at the repository root
+func DetectWorkspace(root string) []string {
+	if root == "" {
+		return nil
+	}
+
+	var patterns []string
+	patterns = append(patterns, goWorkMembers(filepath.Join(root, "go.work"))...)
+	patterns = append(patterns, packageJSONMembers(filepath.Join(root, "package.json"))...)
+	patterns = append(patterns, pnpmMembers(filepath.Join(root, "pnpm-workspace.yaml"))...)
+	patterns = append(patterns, cargoMembers(filepath.Join(root, "Cargo.toml"))...)
+
+	seen := map[string]bool{}
+	var members []string
+	for _, p := range patterns {
+		p = strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(p), "./"), "/")
+		if p == "" || p == "." || strings.HasPrefix(p, "!") {
+			continue
+		}
+		matches := []string{p}
+		if strings.ContainsAny(p, "*?[") {
+			matches, _ = filepath.Glob(filepath.Join(root, filepath.FromSlash(p)))
+			for i := range matches {
+				rel, _ := filepath.Rel(root, matches[i])
+				matches[i] = filepath.ToSlash(rel)
+			}
+		}
+		for _, m := range matches {
+			if info, err := os.Stat(filepath.Join(root, m)); err == nil && info.IsDir() && !seen[m] {
+				seen[m] = true
+				members = append(members, m)
+			}
+		}
+	}
+	sort.Strings(members)
+	return members
+}
+
+// goWorkMembers parses the `use` directives of a go.work file
+func goWorkMembers(file string) []string {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var members []string
+	inBlock := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock && line != "":
+			members = append(members, strings.Trim(line, `"`))
+		case line == "use (":
+			inBlock = true
+		case strings.HasPrefix(line, "use "):
+			members = append(members, strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "use ")), `"`))
+		}
+	}
+	return members
+}
+
+// packageJSONMembers reads npm/yarn workspaces, either an array or {"packages": [...]}
+func packageJSONMembers(file string) []string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if json.Unmarshal(data, &pkg) != nil || len(pkg.Workspaces) == 0 {
+		return nil
+	}
+
+	var list []string
+	if json.Unmarshal(pkg.Workspaces, &list) == nil {
+		return list
+	}
+	var obj struct {
+		Packages []string `json:"packages"`
+	}
+	if json.Unmarshal(pkg.Workspaces, &obj) == nil {
+		return obj.Packages
+	}
+	return nil
+}
+
+func pnpmMembers(file string) []string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	var ws struct {
+		Packages []string `yaml:"packages"`
+	}
+	if yaml.Unmarshal(data, &ws) != nil {
+		return nil
+	}
+	return ws.Packages
+}
+
+var (
+	cargoMembersPattern = regexp.MustCompile(`(?s)members\s*=\s*\[(.*?)\]`)
+	tomlTablePattern    = regexp.MustCompile(`(?m)^\[[^\]]+\]`)
+	quotedPattern       = regexp.MustCompile(`"([^"]+)"`)
+)
+
+// cargoMembers reads `members` from the [workspace] table of a Cargo.toml
+func cargoMembers(file string) []string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	content := string(data)
+	start := strings.Index(content, "[workspace]")
+	if start < 0 {
+		return nil
+	}
+	section := content[start+len("[workspace]"):]
+	if next := tomlTablePattern.FindStringIndex(section); next != nil {
+		section = section[:next[0]]
+	}
+
+	m := cargoMembersPattern.FindStringSubmatch(section)
+	if m == nil {
+		return nil
+	}
+	var members []string
+	for _, q := range quotedPattern.FindAllStringSubmatch(m[1], -1) {
+		members = append(members, q[1])
+	}
+	return members
+}
@@ -0,0 +1,63 @@
+package scope
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestInfer_RulesWorkspaceAndFallback covers the lookup order used to pick a scope.
+func TestInfer_RulesWorkspaceAndFallback(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"services/billing", "tools/gen"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	goWork := "go 1.25\n\nuse (\n\t./services/billing // billing service\n)\n\nuse ./tools/gen\n"
+	if err := os.WriteFile(filepath.Join(root, "go.work"), []byte(goWork), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	in := New(root, []Rule{{Scope: "api", Paths: []string{"internal/api/**", "cmd/server"}}}, true)
+
+	cases := []struct {
+		paths []string
+		want  string
+	}{
+		{paths: []string{"internal/api/v1/users.go", "cmd/server/main.go"}, want: "api"},
+		{paths: []string{"services/billing/invoice.go", "README.md"}, want: "billing"},
+		{paths: []string{"internal/llm/llm.go", "internal/llm/llm_test.go"}, want: "llm"},
+		{paths: []string{"internal/llm/llm.go", "tools/gen/main.go"}, want: ""},
+		{paths: []string{".github/workflows/release.yml"}, want: ""},
+	}
+
+	for _, tc := range cases {
+		if got := in.Infer(tc.paths); got != tc.want {
+			t.Errorf("Infer(%v) = %q, want %q", tc.paths, got, tc.want)
+		}
+	}
+}
+
+// TestDetectWorkspace_PackageJSONAndCargo expands workspace globs from npm and Cargo manifests.
+func TestDetectWorkspace_PackageJSONAndCargo(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"packages/ui", "packages/core", "crates/parser"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = os.WriteFile(filepath.Join(root, "package.json"), []byte(`{"workspaces": {"packages": ["packages/*"]}}`), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "Cargo.toml"), []byte("[workspace]\nmembers = [\n  \"crates/parser\",\n]\n\n[workspace.dependencies]\nserde = \"1\"\n"), 0o644)
+
+	got := DetectWorkspace(root)
+	want := []string{"crates/parser", "packages/core", "packages/ui"}
+	if len(got) != len(want) {
+		t.Fatalf("DetectWorkspace() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("DetectWorkspace() = %v, want %v", got, want)
+		}
+	}
+}
@@ -13,35 +13,11 @@ import (
 	"github.com/manifoldco/promptui"
 
 	"github.com/edhuardotierrez/gommit/internal/colors"
+	"github.com/edhuardotierrez/gommit/internal/config"
 	"github.com/edhuardotierrez/gommit/internal/llm"
 	"github.com/edhuardotierrez/gommit/internal/types"
 )
 
-// --- helpers: configuration IO ---
-
-func readConfigFromPath(configPath string) (*types.Config, error) {
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("could not read config file: %w", err)
-	}
-	var cfg types.Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("could not parse config: %w", err)
-	}
-	return &cfg, nil
-}
-
-func writeConfigToPath(configPath string, cfg *types.Config) error {
-	out, err := json.MarshalIndent(cfg, "", "    ")
-	if err != nil {
-		return fmt.Errorf("could not marshal config: %w", err)
-	}
-	if err := os.WriteFile(configPath, out, 0600); err != nil {
-		return fmt.Errorf("could not write config: %w", err)
-	}
-	return nil
-}
-
 // --- helpers: providers ---
 
 func sortedProviderTitles() []string {
@@ -72,15 +48,6 @@ func providerDisplayList(cfg *types.Config, titles []string) []string {
 	return display
 }
 
-func findProviderMetaByTitle(title string) (types.ProviderTypes, bool) {
-	for _, p := range llm.Providers {
-		if p.Title == title {
-			return p, true
-		}
-	}
-	return types.ProviderTypes{}, false
-}
-
 // --- helpers: prompts ---
 
 func selectIndex(label string, items []string) (int, error) {
@@ -92,15 +59,51 @@ func selectIndex(label string, items []string) (int, error) {
 	return idx, nil
 }
 
-func chooseModelForProvider(providerTitle, current string) (string, bool, error) {
-	models := llm.GetAvailableModels(types.ProviderName(providerTitle))
+// discoverModels lists the models the provider offers to pc's credentials, falling back to the
+// built-in list (with a warning) when the provider cannot be reached
+func discoverModels(providerTitle string, pc types.ProviderConfig) []string {
+	provider := types.ProviderName(providerTitle)
+	colors.DescOutput("Fetching %s models...\n", providerTitle)
+	list := llm.ListModels(provider, llm.WithEnvCredentials(provider, pc), false)
+	if list.Err != nil {
+		colors.WarningOutput("⚠️ Could not list %s models (%v), showing the built-in list\n", providerTitle, list.Err)
+	}
+	return list.Models
+}
+
+// checkProvider verifies the credentials and model with the provider before they are saved. When the
+// check fails the user decides whether to save anyway (e.g. when configuring offline).
+func checkProvider(providerTitle string, pc types.ProviderConfig) bool {
+	colors.DescOutput("Checking %s...\n", providerTitle)
+	_, err := llm.CheckProvider(types.ProviderName(providerTitle), pc)
+	if err == nil {
+		colors.SuccessOutput("✅ %s is reachable and model %s is available\n\n", providerTitle, pc.Model)
+		return true
+	}
+	colors.ErrorOutput("❌ %s check failed: %v\n", providerTitle, err)
+
+	prompt := promptui.Prompt{Label: "Save this provider anyway", IsConfirm: true}
+	_, err = prompt.Run()
+	return err == nil
+}
+
+// searchModels lets long model lists be filtered by typing "/" and part of the name
+func searchModels(items []string) func(input string, index int) bool {
+	return func(input string, index int) bool {
+		return strings.Contains(strings.ToLower(items[index]), strings.ToLower(strings.TrimSpace(input)))
+	}
+}
+
+func chooseModelForProvider(providerTitle string, pc types.ProviderConfig) (string, bool, error) {
+	current := pc.Model
+	models := discoverModels(providerTitle, pc)
 	if len(models) == 0 {
 		return current, false, nil
 	}
 	display := make([]string, 0, len(models)+1)
 	display = append(display, fmt.Sprintf("(keep current) %s", current))
 	display = append(display, models...)
-	s := promptui.Select{Label: "Select model", Items: display}
+	s := promptui.Select{Label: "Select model", Items: display, Size: 10, Searcher: searchModels(display)}
 	_, choice, err := s.Run()
 	if err != nil {
 		return current, false, err
@@ -115,9 +118,8 @@ func chooseModelForProvider(providerTitle, current string) (string, bool, error)
 
 func ensureConfigPresenceWithDefaults(configPath string) error {
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		empty := &types.Config{DefaultProvider: "openai", Providers: map[string]types.ProviderConfig{"openai": {Model: "gpt-4o-mini", Temperature: 0.7}}}
-		data, _ := json.MarshalIndent(empty, "", "    ")
-		if writeErr := os.WriteFile(configPath, data, 0600); writeErr != nil {
+		empty := &types.Config{DefaultProvider: "openai", Providers: map[string]types.ProviderConfig{"openai": {Model: "gpt-4o-mini", Temperature: types.DefaultTemperature}}}
+		if writeErr := config.Save(configPath, empty); writeErr != nil {
 			return fmt.Errorf("could not create default config: %w", writeErr)
 		}
 	}
@@ -223,10 +225,12 @@ func CreateConfigWizard(configPath string) (*types.Config, error) {
 	}
 
 	// Select model for the provider
-	models := llm.GetAvailableModels(types.ProviderName(provider))
+	models := discoverModels(provider, types.ProviderConfig{APIKey: apiKey, URI: uri})
 	modelSelect := promptui.Select{
-		Label: fmt.Sprintf("Select %s model", provider),
-		Items: models,
+		Label:    fmt.Sprintf("Select %s model", provider),
+		Items:    models,
+		Size:     10,
+		Searcher: searchModels(models),
 	}
 
 	_, model, err := modelSelect.Run()
@@ -276,8 +280,7 @@ func CreateConfigWizard(configPath string) (*types.Config, error) {
 
 	// Ask for max tokens (optional)
 	maxTokensPrompt := promptui.Prompt{
-		Label:     fmt.Sprintf("Max tokens for responses (default: %d, press enter to skip)", types.DefaultMaxTokens),
-		Default:   fmt.Sprintf("%d", types.DefaultMaxTokens),
+		Label:     "Max tokens for responses (0 or enter for the provider's default)",
 		AllowEdit: true,
 		Validate: func(input string) error {
 			if input == "" {
@@ -285,8 +288,8 @@ func CreateConfigWizard(configPath string) (*types.Config, error) {
 			}
 			var tokens int
 			_, err := fmt.Sscanf(input, "%d", &tokens)
-			if err != nil || tokens < 1 {
-				return fmt.Errorf("please enter a valid positive number")
+			if err != nil || tokens < 0 {
+				return fmt.Errorf("please enter a valid number")
 			}
 			return nil
 		},
@@ -297,7 +300,7 @@ func CreateConfigWizard(configPath string) (*types.Config, error) {
 		return nil, fmt.Errorf("max tokens input failed: %w", err)
 	}
 
-	maxTokens := types.DefaultMaxTokens
+	maxTokens := 0
 	if maxTokensStr != "" {
 		fmt.Sscanf(maxTokensStr, "%d", &maxTokens)
 	}
@@ -317,6 +320,10 @@ func CreateConfigWizard(configPath string) (*types.Config, error) {
 		CommitStyle: commitStyle,
 	}
 
+	if !checkProvider(provider, cfg.Providers[provider]) {
+		return nil, fmt.Errorf("configuration cancelled by user")
+	}
+
 	// Show final configuration
 	data, err := json.MarshalIndent(cfg, "", "    ")
 	if err != nil {
@@ -336,7 +343,7 @@ func CreateConfigWizard(configPath string) (*types.Config, error) {
 	}
 
 	// Save configuration
-	if err := os.WriteFile(configPath, data, 0600); err != nil {
+	if err := config.Save(configPath, cfg); err != nil {
 		return nil, fmt.Errorf("could not write config file: %w", err)
 	}
 
@@ -352,11 +359,16 @@ func EditConfigInEditor(configPath string) error {
 		return err
 	}
 
+	return EditFile(configPath)
+}
+
+// EditFile opens a file in the user's editor (VISUAL/EDITOR) or falls back to common editors.
+func EditFile(path string) error {
 	cmdName, args, err := resolveEditorCommand()
 	if err != nil {
 		return err
 	}
-	cmd := exec.Command(cmdName, append(args, configPath)...)
+	cmd := exec.Command(cmdName, append(args, path)...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -366,7 +378,7 @@ func EditConfigInEditor(configPath string) error {
 // EditProviderWizard lets the user choose a provider from the config and edit fields.
 func EditProviderWizard(configPath string) error {
 	// Load existing config
-	cfg, err := readConfigFromPath(configPath)
+	cfg, err := config.LoadFile(configPath)
 	if err != nil {
 		return err
 	}
@@ -388,7 +400,7 @@ func EditProviderWizard(configPath string) error {
 	pc := cfg.Providers[selected]
 
 	// Find provider meta
-	providerMeta, _ := findProviderMetaByTitle(selected)
+	providerMeta, _ := llm.ProviderByTitle(selected)
 
 	// API Key (masked). Leave empty to keep unchanged.
 	apiKeyPrompt := promptui.Prompt{
@@ -405,7 +417,7 @@ func EditProviderWizard(configPath string) error {
 	}
 
 	// Model
-	if model, changed, err := chooseModelForProvider(selected, pc.Model); err != nil {
+	if model, changed, err := chooseModelForProvider(selected, pc); err != nil {
 		return fmt.Errorf("model selection failed: %w", err)
 	} else if changed {
 		pc.Model = model
@@ -451,9 +463,13 @@ func EditProviderWizard(configPath string) error {
 		}
 	}
 
+	if !checkProvider(selected, pc) {
+		return fmt.Errorf("provider update cancelled by user")
+	}
+
 	// Save back
 	cfg.Providers[selected] = pc
-	if err := writeConfigToPath(configPath, cfg); err != nil {
+	if err := config.Save(configPath, cfg); err != nil {
 		return err
 	}
 
@@ -464,7 +480,7 @@ func EditProviderWizard(configPath string) error {
 // EditDefaultsWizard lets the user change non-provider settings: default_provider, max_tokens, commit_style, max_line_width.
 func EditDefaultsWizard(configPath string) error {
 	// Load existing config
-	cfg, err := readConfigFromPath(configPath)
+	cfg, err := config.LoadFile(configPath)
 	if err != nil {
 		return err
 	}
@@ -503,15 +519,15 @@ func EditDefaultsWizard(configPath string) error {
 
 	// Max tokens
 	maxTokensPrompt := promptui.Prompt{
-		Label:     fmt.Sprintf("Max tokens (current: %d, blank to keep)", cfg.MaxTokens),
+		Label:     fmt.Sprintf("Max tokens (current: %d, 0 = provider default, blank to keep)", cfg.MaxTokens),
 		AllowEdit: true,
 		Validate: func(input string) error {
 			if strings.TrimSpace(input) == "" {
 				return nil
 			}
 			v, err := strconv.Atoi(strings.TrimSpace(input))
-			if err != nil || v < 1 {
-				return fmt.Errorf("enter a positive integer")
+			if err != nil || v < 0 {
+				return fmt.Errorf("enter a non-negative integer")
 			}
 			return nil
 		},
@@ -550,5 +566,5 @@ func EditDefaultsWizard(configPath string) error {
 	}
 
 	// Save back
-	return writeConfigToPath(configPath, cfg)
+	return config.Save(configPath, cfg)
 }
@@ -0,0 +1,51 @@
+package ticket
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/edhuardotierrez/gommit/internal/git"
+	"github.com/edhuardotierrez/gommit/internal/lint"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// Extract returns the first ticket found in the branch name. Each pattern is a regexp whose first
+// capture group (or whole match, when it has none) is the ticket; DefaultTicketPattern is used when
+// no patterns are configured.
+func Extract(branch string, patterns []string) (string, error) {
+	if branch == "" {
+		return "", nil
+	}
+	if len(patterns) == 0 {
+		patterns = []string{lint.DefaultTicketPattern}
+	}
+
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return "", fmt.Errorf("invalid branch pattern %q: %w", p, err)
+		}
+		m := re.FindStringSubmatch(branch)
+		switch {
+		case m == nil:
+			continue
+		case len(m) > 1 && m[1] != "":
+			return m[1], nil
+		default:
+			return m[0], nil
+		}
+	}
+	return "", nil
+}
+
+// FromBranch extracts the ticket from the current branch (or the branch being rebased) when the
+// config asks for tickets: enabled, required or with its own branch patterns. It returns the ticket
+// and the branch it came from; both are empty on a detached HEAD.
+func FromBranch(cfg types.TicketConfig) (string, string, error) {
+	if !cfg.Enabled && !cfg.Required && len(cfg.BranchPatterns) == 0 {
+		return "", "", nil
+	}
+	branch := git.GetCurrentBranch()
+	ticket, err := Extract(branch, cfg.BranchPatterns)
+	return ticket, branch, err
+}
@@ -0,0 +1,28 @@
+package ticket
+
+import "testing"
+
+// TestExtract covers the default pattern, custom capture groups and branches without tickets.
+func TestExtract(t *testing.T) {
+	cases := []struct {
+		branch   string
+		patterns []string
+		want     string
+	}{
+		{branch: "feature/PROJ-1234-short-desc", want: "PROJ-1234"},
+		{branch: "bugfix/ab-12-lowercase", want: ""},
+		{branch: "gh-42/fix-login", patterns: []string{`^gh-(\d+)/`}, want: "42"},
+		{branch: "main", want: ""},
+		{branch: "", want: ""},
+	}
+
+	for _, tc := range cases {
+		got, err := Extract(tc.branch, tc.patterns)
+		if err != nil {
+			t.Fatalf("Extract(%q) failed: %v", tc.branch, err)
+		}
+		if got != tc.want {
+			t.Errorf("Extract(%q) = %q, want %q", tc.branch, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package trailer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// linePattern matches a git trailer line such as "Signed-off-by: Jane <jane@example.com>"
+var linePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: \S.*$`)
+
+// IsLine reports whether a single line is formatted as a trailer
+func IsLine(line string) bool {
+	return linePattern.MatchString(strings.TrimSpace(line))
+}
+
+// CoAuthor formats a Co-authored-by trailer for "Name <email>"
+func CoAuthor(author string) string {
+	return "Co-authored-by: " + strings.TrimSpace(author)
+}
+
+// Append adds lines to the message's trailer block (its last paragraph, when that paragraph only
+// contains trailers and is not the subject), creating the block otherwise. Lines already present
+// are not duplicated, so applying the same trailers twice is a no-op.
+func Append(message string, lines ...string) string {
+	message = strings.TrimRight(message, "\n ")
+	paragraphs := strings.Split(message, "\n\n")
+
+	last := paragraphs[len(paragraphs)-1]
+	hasBlock := len(paragraphs) > 1 && isBlock(last)
+
+	existing := map[string]bool{}
+	if hasBlock {
+		for _, l := range strings.Split(last, "\n") {
+			existing[strings.ToLower(strings.TrimSpace(l))] = true
+		}
+	}
+
+	var add []string
+	for _, l := range lines {
+		l = strings.TrimSpace(l)
+		if l == "" || existing[strings.ToLower(l)] {
+			continue
+		}
+		existing[strings.ToLower(l)] = true
+		add = append(add, l)
+	}
+	if len(add) == 0 {
+		return message
+	}
+
+	if hasBlock {
+		return message + "\n" + strings.Join(add, "\n")
+	}
+	return message + "\n\n" + strings.Join(add, "\n")
+}
+
+func isBlock(paragraph string) bool {
+	for _, l := range strings.Split(paragraph, "\n") {
+		if !IsLine(l) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package trailer
+
+import "testing"
+
+// TestAppend covers new blocks, merging into an existing block and idempotence.
+func TestAppend(t *testing.T) {
+	coAuthor := CoAuthor("Jane Doe <jane@example.com>")
+
+	cases := []struct {
+		name    string
+		message string
+		lines   []string
+		want    string
+	}{
+		{
+			name:    "subject only",
+			message: "feat: add login",
+			lines:   []string{coAuthor},
+			want:    "feat: add login\n\nCo-authored-by: Jane Doe <jane@example.com>",
+		},
+		{
+			name:    "body is not a trailer block",
+			message: "feat: add login\n\nAdds the login page.",
+			lines:   []string{"Reviewed-by: Bob <bob@example.com>", coAuthor},
+			want:    "feat: add login\n\nAdds the login page.\n\nReviewed-by: Bob <bob@example.com>\nCo-authored-by: Jane Doe <jane@example.com>",
+		},
+		{
+			name:    "merges into existing block",
+			message: "feat: add login\n\nRefs: PROJ-7",
+			lines:   []string{coAuthor},
+			want:    "feat: add login\n\nRefs: PROJ-7\nCo-authored-by: Jane Doe <jane@example.com>",
+		},
+		{
+			name:    "already present",
+			message: "feat: add login\n\nco-authored-by: Jane Doe <jane@example.com>",
+			lines:   []string{coAuthor},
+			want:    "feat: add login\n\nco-authored-by: Jane Doe <jane@example.com>",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Append(tc.message, tc.lines...)
+			if got != tc.want {
+				t.Fatalf("Append() = %q, want %q", got, tc.want)
+			}
+			if again := Append(got, tc.lines...); again != got {
+				t.Fatalf("Append() is not idempotent: %q", again)
+			}
+		})
+	}
+}
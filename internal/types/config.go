@@ -1,5 +1,7 @@
 package types
 
+import "strings"
+
 // ProviderConfig holds the configuration for a specific LLM provider
 type ProviderConfig struct {
 	APIKey      string  `json:"api_key,omitempty"`
@@ -11,17 +13,178 @@ type ProviderConfig struct {
 
 // Config holds the application configuration
 type Config struct {
-	DefaultProvider string                    `json:"default_provider"`
-	Providers       map[string]ProviderConfig `json:"providers"`
-	MaxTokens       int                       `json:"max_tokens"`
-	CommitStyle     string                    `json:"commit_style"`
+	Version         int                       `json:"version,omitempty"` // format of the file, see config.CurrentVersion
+	DefaultProvider string                    `json:"default_provider,omitempty"`
+	Providers       map[string]ProviderConfig `json:"providers,omitempty"`
+	MaxTokens       int                       `json:"max_tokens,omitempty"` // 0 leaves the limit to the provider
+	CommitStyle     string                    `json:"commit_style,omitempty"`
 	TruncateLines   int                       `json:"truncate_lines,omitempty"`
-	MaxLineWidth    int                       `json:"max_line_width"`
+	MaxLineWidth    int                       `json:"max_line_width,omitempty"`
+	Lint            LintConfig                `json:"lint,omitempty"`
+	Scope           ScopeConfig               `json:"scope,omitempty"`
+	Ticket          TicketConfig              `json:"ticket,omitempty"`
+	History         HistoryConfig             `json:"history,omitempty"`
+	Trailers        []string                  `json:"trailers,omitempty"` // appended to every message, e.g. "Reviewed-by: Jane <jane@example.com>"
+	Signoff         bool                      `json:"signoff,omitempty"`  // always commit with --signoff (DCO)
+	Cache           CacheConfig               `json:"cache,omitempty"`
+	Usage           UsageConfig               `json:"usage,omitempty"`
+	Audit           AuditConfig               `json:"audit,omitempty"`
+	Context         ContextConfig             `json:"context,omitempty"`
+	Summarize       SummarizeConfig           `json:"summarize,omitempty"`
+	Candidates      []CandidateConfig         `json:"candidates,omitempty"` // variants used by `gommit -n`
+	Policies        []PolicyConfig            `json:"policies,omitempty"`   // providers and endpoints allowed per repository
+	Shared          SharedConfig              `json:"shared,omitempty"`
+	Locked          []string                  `json:"locked,omitempty"` // dotted keys the user and repository config cannot override
+}
+
+// PolicyConfig restricts the providers and endpoints a repository's changes may be sent to. In the
+// config it applies to the repositories whose origin matches Remote (all of them when it is empty);
+// in .gommitrules to that repository. A deny rule always wins; with allow rules, anything they do
+// not match is refused.
+type PolicyConfig struct {
+	Remote string       `json:"remote,omitempty" yaml:"-"` // glob matched against the origin URL, e.g. "github.com/acme/secret-*"
+	Allow  []PolicyRule `json:"allow,omitempty" yaml:"allow,omitempty"`
+	Deny   []PolicyRule `json:"deny,omitempty" yaml:"deny,omitempty"`
+}
+
+// PolicyRule matches a provider, an endpoint or both; an empty field matches anything
+type PolicyRule struct {
+	Provider string `json:"provider,omitempty" yaml:"provider,omitempty"` // provider name, e.g. "ollama"
+	Endpoint string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"` // glob matched against the API base URL, or its host when it has no scheme
+}
+
+// SharedConfig points to a team-wide config merged between the system and the user config
+type SharedConfig struct {
+	Source       string `json:"source,omitempty"`        // https URL, git+<repository>#<path>, or a local file
+	RefreshHours int    `json:"refresh_hours,omitempty"` // how long a fetched copy is used (default 24)
+}
+
+// IsLocked reports whether key (a dotted path such as "history.enabled") is covered by the locked
+// keys. An entry locks its whole subtree, and "*" matches any one part (e.g. "providers.*.uri").
+// Locking part of a section also locks replacing the section as a whole.
+func (c *Config) IsLocked(key string) bool {
+	parts := strings.Split(key, ".")
+	for _, entry := range c.Locked {
+		locked := strings.Split(entry, ".")
+		match := true
+		for i := 0; i < len(parts) && i < len(locked); i++ {
+			if locked[i] != "*" && locked[i] != parts[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// CandidateConfig describes one candidate of `gommit -n`; empty fields fall back to the selected provider
+type CandidateConfig struct {
+	Provider    string   `json:"provider,omitempty"`
+	Model       string   `json:"model,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	CommitStyle string   `json:"commit_style,omitempty"`
+}
+
+// SummarizeConfig controls the two-stage (map-reduce) mode for very large changesets: groups of files
+// are summarized in parallel with a cheap model, then the message is written from the summaries
+type SummarizeConfig struct {
+	Disabled     bool   `json:"disabled,omitempty"`
+	MinFiles     int    `json:"min_files,omitempty"`      // changed files that trigger the mode (default 50)
+	MinDiffChars int    `json:"min_diff_chars,omitempty"` // total diff size that triggers the mode (default 100000)
+	Model        string `json:"model,omitempty"`          // model for the per-group summaries (default: a cheap model of the provider)
+	Concurrency  int    `json:"concurrency,omitempty"`    // parallel summary requests (default 4)
+	ChunkChars   int    `json:"chunk_chars,omitempty"`    // diff characters per summary request (default 12000)
+}
+
+// ContextConfig controls enriching the prompt with code around each change. It is off by default
+// because it sends more of the repository's source to the provider.
+type ContextConfig struct {
+	Enabled     bool     `json:"enabled,omitempty"`
+	Languages   []string `json:"languages,omitempty"`    // restrict enclosing declarations to these languages (default: all)
+	FileHistory int      `json:"file_history,omitempty"` // previous commit subjects per file (default 3, -1 disables)
+	MaxChars    int      `json:"max_chars,omitempty"`    // cap on the context text in the prompt (default 2000)
+}
+
+// UsageConfig controls the local usage log, cost estimates and the monthly budget
+type UsageConfig struct {
+	Disabled      bool             `json:"disabled,omitempty"`
+	Prices        map[string]Price `json:"prices,omitempty"`         // per model, overrides the built-in price table
+	MonthlyBudget float64          `json:"monthly_budget,omitempty"` // estimated USD per calendar month; 0 disables it
+	BudgetAction  string           `json:"budget_action,omitempty"`  // warn or block once the budget is spent (default: warn)
+}
+
+// AuditConfig controls the opt-in audit log of the prompts sent to each provider
+type AuditConfig struct {
+	Enabled       bool `json:"enabled,omitempty"`
+	StorePrompts  bool `json:"store_prompts,omitempty"`  // keep the full prompts, not only their hashes
+	RetentionDays int  `json:"retention_days,omitempty"` // days the stored prompts are kept (default 30)
+}
+
+// Price is the cost of a model in USD per million tokens
+type Price struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// CacheConfig controls reusing generated messages for identical changes (stored in .git/gommit)
+type CacheConfig struct {
+	Disabled    bool `json:"disabled,omitempty"`
+	MaxAgeHours int  `json:"max_age_hours,omitempty"` // entries older than this are ignored and pruned (default 168)
+}
+
+// HistoryConfig controls sampling the repository's recent commits as few-shot examples.
+// It can also be set per repository in the `history` section of .gommitrules.
+type HistoryConfig struct {
+	Enabled          bool     `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Commits          int      `json:"commits,omitempty" yaml:"commits,omitempty"`     // commits sampled from git log (default 50)
+	Examples         int      `json:"examples,omitempty" yaml:"examples,omitempty"`   // examples included in the prompt (default 5)
+	MaxChars         int      `json:"max_chars,omitempty" yaml:"max_chars,omitempty"` // cap on the history text in the prompt (default 2000)
+	IncludeBodies    bool     `json:"include_bodies,omitempty" yaml:"include_bodies,omitempty"`
+	TouchedPathsOnly bool     `json:"touched_paths_only,omitempty" yaml:"touched_paths_only,omitempty"`
+	ExcludeAuthors   []string `json:"exclude_authors,omitempty" yaml:"exclude_authors,omitempty"` // extra bot name/email patterns
+}
+
+// TicketConfig controls extracting issue references from the branch name and placing them in the message.
+// It is off unless Enabled, Required or BranchPatterns is set.
+type TicketConfig struct {
+	Enabled        bool     `json:"enabled,omitempty"`
+	BranchPatterns []string `json:"branch_patterns,omitempty"` // regexps; the first capture group (or whole match) is the ticket
+	Placement      string   `json:"placement,omitempty"`       // prefix, footer or trailer (default: footer)
+	TrailerKey     string   `json:"trailer_key,omitempty"`     // key used by footer/trailer placement (default: Refs)
+	Required       bool     `json:"required,omitempty"`        // report messages without any ticket reference
+}
+
+// ScopeConfig controls conventional-commit scope inference from the changed paths
+type ScopeConfig struct {
+	Disabled   bool        `json:"disabled,omitempty"`
+	Rules      []ScopeRule `json:"rules,omitempty"`       // checked after the .gommitrules scopes
+	NoFallback bool        `json:"no_fallback,omitempty"` // don't fall back to the top-level package or directory
+}
+
+// ScopeRule maps path globs (e.g. "internal/api/**") to a scope
+type ScopeRule struct {
+	Scope string   `json:"scope"`
+	Paths []string `json:"paths"`
+}
+
+// LintConfig holds the rules used to validate (and auto-repair) commit messages
+type LintConfig struct {
+	SubjectMaxLength int      `json:"subject_max_length,omitempty"`
+	BodyMaxLineWidth int      `json:"body_max_line_width,omitempty"`
+	Types            []string `json:"types,omitempty"`
+	Scopes           []string `json:"scopes,omitempty"`
+	RequireScope     bool     `json:"require_scope,omitempty"`
+	ForbiddenPhrases []string `json:"forbidden_phrases,omitempty"`
+	Disable          []string `json:"disable,omitempty"`     // rule names to skip, or "all"
+	MaxRetries       int      `json:"max_retries,omitempty"` // re-prompts for unfixable violations (-1 disables)
 }
 
 // Default values for configuration
 const (
-	DefaultMaxTokens     = 500
+	DefaultTemperature   = 0.7
 	DefaultCommitStyle   = "conventional" // can be: conventional, simple, detailed
 	DefaultTruncateLines = 1000           // default number of context lines in git diff
 	DefaultMaxLineWidth  = 300
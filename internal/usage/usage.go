@@ -0,0 +1,200 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// Budget actions
+const (
+	BudgetWarn  = "warn"
+	BudgetBlock = "block"
+)
+
+// DefaultPrices are list prices in USD per million tokens for the models gommit suggests.
+// Set `usage.prices` in the config to correct or extend them; unknown models cost 0.
+var DefaultPrices = map[string]types.Price{
+	"gpt-5":                    {Input: 1.25, Output: 10},
+	"gpt-5-mini":               {Input: 0.25, Output: 2},
+	"gpt-5-nano":               {Input: 0.05, Output: 0.40},
+	"gpt-4o":                   {Input: 2.50, Output: 10},
+	"gpt-4o-mini":              {Input: 0.15, Output: 0.60},
+	"gpt-4.1-mini":             {Input: 0.40, Output: 1.60},
+	"gpt-4.1-nano":             {Input: 0.10, Output: 0.40},
+	"claude-opus-4-1":          {Input: 15, Output: 75},
+	"claude-sonnet-4-0":        {Input: 3, Output: 15},
+	"claude-3-7-sonnet-latest": {Input: 3, Output: 15},
+	"claude-3-5-sonnet-latest": {Input: 3, Output: 15},
+	"claude-3-5-haiku-latest":  {Input: 0.80, Output: 4},
+	"claude-3-haiku-20240307":  {Input: 0.25, Output: 1.25},
+	"gemini-2.5-pro":           {Input: 1.25, Output: 10},
+	"gemini-2.5-flash":         {Input: 0.30, Output: 2.50},
+	"gemini-2.5-flash-lite":    {Input: 0.10, Output: 0.40},
+}
+
+// Record is one provider call in the usage log
+type Record struct {
+	Time             time.Time `json:"time"`
+	Repo             string    `json:"repo"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	LatencyMs        int64     `json:"latency_ms"`
+}
+
+// LogPath returns the usage log location: $XDG_STATE_HOME/gommit/usage.jsonl, or ~/.local/state/gommit/usage.jsonl
+func LogPath() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "gommit", "usage.jsonl")
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "gommit-usage.jsonl" // fallback to current directory
+	}
+	return filepath.Join(homeDir, ".local", "state", "gommit", "usage.jsonl")
+}
+
+// Append adds a record to the usage log
+func Append(r Record) error {
+	path := LogPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("could not create usage log directory: %w", err)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not open usage log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("could not write usage log: %w", err)
+	}
+	return nil
+}
+
+// Read returns the records logged at or after since; malformed lines are skipped
+func Read(since time.Time) ([]Record, error) {
+	f, err := os.Open(LogPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open usage log: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		if json.Unmarshal(scanner.Bytes(), &r) != nil || r.Time.Before(since) {
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// Cost estimates the USD cost of a record; configured prices take precedence over DefaultPrices
+func Cost(r Record, prices map[string]types.Price) float64 {
+	price, ok := prices[r.Model]
+	if !ok {
+		price = DefaultPrices[r.Model]
+	}
+	return (float64(r.PromptTokens)*price.Input + float64(r.CompletionTokens)*price.Output) / 1_000_000
+}
+
+// MonthStart returns the first instant of the month containing t
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// CheckBudget compares the estimated spend of the current month with the configured budget.
+// It returns the spend and whether the budget is exceeded; a zero budget is never exceeded.
+func CheckBudget(cfg types.UsageConfig) (float64, bool, error) {
+	if cfg.MonthlyBudget <= 0 {
+		return 0, false, nil
+	}
+	records, err := Read(MonthStart(time.Now()))
+	if err != nil {
+		return 0, false, err
+	}
+	spent := 0.0
+	for _, r := range records {
+		spent += Cost(r, cfg.Prices)
+	}
+	return spent, spent >= cfg.MonthlyBudget, nil
+}
+
+// Summary aggregates records under one key (a day, repository, provider or model)
+type Summary struct {
+	Key              string  `json:"key"`
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	AvgLatencyMs     int64   `json:"avg_latency_ms"`
+	Cost             float64 `json:"estimated_cost"`
+}
+
+// Dimensions are the groupings supported by Summarize
+var Dimensions = []string{"day", "repo", "provider", "model"}
+
+// Summarize groups records by a dimension, sorted by key (days newest first)
+func Summarize(records []Record, by string, prices map[string]types.Price) ([]Summary, error) {
+	keyOf := map[string]func(Record) string{
+		"day":      func(r Record) string { return r.Time.Local().Format("2006-01-02") },
+		"repo":     func(r Record) string { return r.Repo },
+		"provider": func(r Record) string { return r.Provider },
+		"model":    func(r Record) string { return r.Model },
+	}[by]
+	if keyOf == nil {
+		return nil, fmt.Errorf("invalid grouping %q (expected: %s)", by, strings.Join(Dimensions, "|"))
+	}
+
+	groups := map[string]*Summary{}
+	var latency = map[string]int64{}
+	for _, r := range records {
+		key := keyOf(r)
+		if key == "" {
+			key = "(unknown)"
+		}
+		s, ok := groups[key]
+		if !ok {
+			s = &Summary{Key: key}
+			groups[key] = s
+		}
+		s.Requests++
+		s.PromptTokens += r.PromptTokens
+		s.CompletionTokens += r.CompletionTokens
+		s.Cost += Cost(r, prices)
+		latency[key] += r.LatencyMs
+	}
+
+	summaries := make([]Summary, 0, len(groups))
+	for key, s := range groups {
+		s.AvgLatencyMs = latency[key] / int64(s.Requests)
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if by == "day" {
+			return summaries[i].Key > summaries[j].Key
+		}
+		return summaries[i].Key < summaries[j].Key
+	})
+	return summaries, nil
+}
@@ -0,0 +1,64 @@
+package usage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// TestSummarizeAndBudget logs a few calls and checks grouping, cost estimates and the budget.
+func TestSummarizeAndBudget(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	now := time.Now()
+	records := []Record{
+		{Time: now, Repo: "/src/api", Provider: "openai", Model: "gpt-4o-mini", PromptTokens: 1_000_000, CompletionTokens: 0, LatencyMs: 100},
+		{Time: now, Repo: "/src/api", Provider: "openai", Model: "gpt-4o-mini", PromptTokens: 0, CompletionTokens: 1_000_000, LatencyMs: 300},
+		{Time: now, Repo: "/src/web", Provider: "ollama", Model: "llama3", PromptTokens: 500, CompletionTokens: 20, LatencyMs: 800},
+		{Time: now.AddDate(0, -2, 0), Repo: "/src/web", Provider: "openai", Model: "gpt-4o", PromptTokens: 10, CompletionTokens: 10},
+	}
+	for _, r := range records {
+		if err := Append(r); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	recent, err := Read(now.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(recent) != 3 {
+		t.Fatalf("Read() returned %d records, want 3", len(recent))
+	}
+
+	byModel, err := Summarize(recent, "model", nil)
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if len(byModel) != 2 || byModel[0].Key != "gpt-4o-mini" || byModel[0].Requests != 2 || byModel[0].AvgLatencyMs != 200 {
+		t.Fatalf("Summarize(model) = %+v", byModel)
+	}
+	if math.Abs(byModel[0].Cost-0.75) > 1e-9 {
+		t.Fatalf("cost = %f, want 0.75 (0.15 input + 0.60 output)", byModel[0].Cost)
+	}
+
+	// Configured prices override the built-in table
+	prices := map[string]types.Price{"llama3": {Input: 1000, Output: 0}}
+	if c := Cost(recent[2], prices); math.Abs(c-0.5) > 1e-9 {
+		t.Fatalf("Cost with custom price = %f, want 0.5", c)
+	}
+
+	if _, err := Summarize(recent, "week", nil); err == nil {
+		t.Fatalf("expected an error for an unknown grouping")
+	}
+
+	spent, exceeded, err := CheckBudget(types.UsageConfig{MonthlyBudget: 0.5})
+	if err != nil || !exceeded || spent < 0.75 {
+		t.Fatalf("CheckBudget() = %f, %v, %v", spent, exceeded, err)
+	}
+	if _, exceeded, _ := CheckBudget(types.UsageConfig{MonthlyBudget: 100}); exceeded {
+		t.Fatalf("budget of 100 should not be exceeded")
+	}
+}
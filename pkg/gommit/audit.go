@@ -0,0 +1,105 @@
+package gommit
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/edhuardotierrez/gommit/internal/audit"
+	"github.com/edhuardotierrez/gommit/internal/colors"
+	"github.com/edhuardotierrez/gommit/internal/config"
+	"github.com/edhuardotierrez/gommit/internal/git"
+)
+
+// auditCommand implements `gommit audit show`: the prompts recorded in the audit log, or one stored prompt
+func auditCommand(fs *flag.FlagSet) func(args []string) int {
+	since := fs.String("since", "30d", "Period to show: a duration in days or weeks (30d, 2w) or a date (2006-01-02)")
+	repoOnly := fs.Bool("repo", false, "Only show the prompts sent for the current repository")
+	prompt := fs.String("prompt", "", "Print the stored prompt with this hash (or a prefix of it)")
+	format := fs.String("format", "text", "Output format: text|json")
+
+	return func(args []string) int {
+		_ = fs.Parse(args)
+		if fs.NArg() == 0 || fs.Arg(0) != "show" {
+			if fs.NArg() > 0 {
+				colors.ErrorOutput("Error: invalid audit subcommand %q (expected: show)\n", fs.Arg(0))
+			} else {
+				fs.Usage()
+			}
+			return 1
+		}
+		// flags may also follow the subcommand
+		_ = fs.Parse(fs.Args()[1:])
+
+		if *prompt != "" {
+			text, _, err := audit.Prompt(*prompt)
+			if err != nil {
+				colors.ErrorOutput("Error: %v\n", err)
+				return 1
+			}
+			fmt.Print(text)
+			return 0
+		}
+
+		if *format != "text" && *format != "json" {
+			colors.ErrorOutput("Error: invalid --format %q (expected: text|json)\n", *format)
+			return 1
+		}
+		from, err := parseSince(*since, time.Now())
+		if err != nil {
+			colors.ErrorOutput("Error: %v\n", err)
+			return 1
+		}
+
+		records, err := audit.Read(from)
+		if err != nil {
+			colors.ErrorOutput("Error: %v\n", err)
+			return 1
+		}
+		if *repoOnly {
+			root := git.GetRootPath()
+			filtered := records[:0]
+			for _, r := range records {
+				if r.Repo == root {
+					filtered = append(filtered, r)
+				}
+			}
+			records = filtered
+		}
+
+		if *format == "json" {
+			out, _ := json.MarshalIndent(records, "", "  ")
+			fmt.Println(string(out))
+			return 0
+		}
+
+		if cfg, err := config.Read(); err == nil && !cfg.Audit.Enabled {
+			colors.WarningOutput("The audit log is off; set audit.enabled to true to record the prompts.\n")
+		}
+		if len(records) == 0 {
+			colors.InfoOutput("No prompts recorded since %s\n", from.Format("2006-01-02"))
+			return 0
+		}
+
+		colors.InfoOutput("Prompts sent since %s (%d)\n", from.Format("2006-01-02"), len(records))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  TIME\tREPO\tPROVIDER\tENDPOINT\tMODEL\tKIND\tSIZE\tHASH")
+		for _, r := range records {
+			hash := r.Hash[:min(12, len(r.Hash))]
+			if r.Stored {
+				hash += " (stored)"
+			}
+			repo := r.Repo
+			if repo != "" {
+				repo = filepath.Base(repo)
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\t%d B\t%s\n", r.Time.Local().Format("2006-01-02 15:04:05"), repo, r.Provider, r.Endpoint, r.Model, r.Kind, r.Bytes, hash)
+		}
+		_ = w.Flush()
+		return 0
+	}
+}
@@ -0,0 +1,215 @@
+package gommit
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/manifoldco/promptui"
+
+	"github.com/edhuardotierrez/gommit/internal/git"
+	"github.com/edhuardotierrez/gommit/internal/lint"
+	"github.com/edhuardotierrez/gommit/internal/llm"
+	"github.com/edhuardotierrez/gommit/internal/setup"
+	"github.com/edhuardotierrez/gommit/internal/trailer"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// candidateTemperatures vary the selected provider when -n asks for more candidates than configured
+var candidateTemperatures = []float64{0.3, 0.9, 0.6, 1.0, 0.2}
+
+// candidate is one message generated by `gommit -n`
+type candidate struct {
+	Provider string
+	Config   types.ProviderConfig
+	Result   *llm.Result
+	Err      error
+}
+
+// Label describes where the candidate comes from, with the temperature actually sent: models that
+// only accept the default temperature are marked as fixed
+func (c candidate) Label() string {
+	t, fixed := llm.Temperature(c.Provider, c.Config)
+	temperature := fmt.Sprintf("t=%.1f", t)
+	if fixed {
+		temperature += " (fixed)"
+	}
+	return fmt.Sprintf("%s/%s, %s, %s", c.Provider, c.Config.Model, temperature, c.Config.CommitStyle)
+}
+
+// planCandidates lists n variants: the selected provider first, then the -candidate flags ("provider" or
+// "provider:model") and the `candidates` config, then the selected provider at other temperatures
+func planCandidates(cfg *types.Config, provider string, selected types.ProviderConfig, n int, extra []string) []candidate {
+	if selected.CommitStyle == "" {
+		selected.CommitStyle = cfg.CommitStyle
+	}
+	plan := []candidate{{Provider: provider, Config: selected}}
+
+	variant := func(c types.CandidateConfig) candidate {
+		name := c.Provider
+		if name == "" {
+			name = provider
+		}
+		pc, ok := cfg.Providers[name]
+		if !ok {
+			return candidate{Provider: name, Config: types.ProviderConfig{Model: c.Model}, Err: fmt.Errorf("provider %q is not configured", name)}
+		}
+		if name == provider {
+			pc = selected
+		}
+		if c.Model != "" {
+			pc.Model = c.Model
+		}
+		if c.Temperature != nil {
+			pc.Temperature = *c.Temperature
+		}
+		if c.CommitStyle != "" {
+			pc.CommitStyle = c.CommitStyle
+		}
+		if pc.CommitStyle == "" {
+			pc.CommitStyle = cfg.CommitStyle
+		}
+		return candidate{Provider: name, Config: pc}
+	}
+
+	for _, e := range extra {
+		name, model, _ := strings.Cut(e, ":")
+		plan = append(plan, variant(types.CandidateConfig{Provider: name, Model: model}))
+	}
+	for _, c := range cfg.Candidates {
+		if len(plan) >= n {
+			break
+		}
+		plan = append(plan, variant(c))
+	}
+	for i := 0; len(plan) < n; i++ {
+		t := candidateTemperatures[i%len(candidateTemperatures)]
+		plan = append(plan, variant(types.CandidateConfig{Temperature: &t}))
+	}
+	return plan
+}
+
+// generateCandidates builds the prompt once and runs every planned candidate's provider call
+// concurrently; a failing candidate does not affect the others
+func generateCandidates(cfg *types.Config, changes []git.StagedChange, plan []candidate) ([]candidate, error) {
+	prompt, err := llm.PreparePrompt(cfg, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	for i := range plan {
+		if plan[i].Err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(c *candidate) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					c.Err = fmt.Errorf("unexpected failure: %v", r)
+				}
+			}()
+			c.Result, c.Err = prompt.Generate(c.Provider, c.Config)
+		}(&plan[i])
+	}
+	wg.Wait()
+	return plan, nil
+}
+
+// candidateItem is a row of the selection list
+type candidateItem struct {
+	Label   string
+	Subject string
+	Message string
+}
+
+// selectCandidate lists the generated candidates with their model and lets the user pick and optionally
+// edit one. It returns the chosen candidate and the final message; ok is false when the user cancels.
+func selectCandidate(candidates []candidate, trailers []string) (candidate, string, bool, error) {
+	var usable []candidate
+	var items []candidateItem
+	for _, c := range candidates {
+		if c.Err != nil {
+			continue
+		}
+		message := trailer.Append(c.Result.Message, trailers...)
+		subject, _, _ := strings.Cut(message, "\n")
+		label := c.Label()
+		if c.Result.Cached {
+			label += ", cached"
+		}
+		if len(c.Result.Warnings) > 0 {
+			label += fmt.Sprintf(", %d rule warnings", len(c.Result.Warnings))
+		}
+		usable = append(usable, c)
+		items = append(items, candidateItem{Label: label, Subject: subject, Message: message})
+	}
+	if len(usable) == 0 {
+		return candidate{}, "", false, fmt.Errorf("no candidate could be generated")
+	}
+
+	list := promptui.Select{
+		Label: "✨ Choose a commit message",
+		Items: items,
+		Size:  min(len(items), 10),
+		Templates: &promptui.SelectTemplates{
+			Label:    "{{ . }}",
+			Active:   "▸ {{ .Subject | cyan }} {{ printf \"(%s)\" .Label | faint }}",
+			Inactive: "  {{ .Subject }} {{ printf \"(%s)\" .Label | faint }}",
+			Selected: "✔ {{ .Subject }}",
+			Details:  "\n--------- Full message ({{ .Label }}) ---------\n{{ .Message }}",
+		},
+	}
+	idx, _, err := list.Run()
+	if err != nil {
+		return candidate{}, "", false, nil
+	}
+	chosen, message := usable[idx], items[idx].Message
+
+	action := promptui.Select{
+		Label: "✨ Commit with this message",
+		Items: []string{"Commit", "Edit before committing", "Cancel"},
+	}
+	choice, _, err := action.Run()
+	if err != nil || choice == 2 {
+		return candidate{}, "", false, nil
+	}
+	if choice == 1 {
+		if message, err = editMessage(message); err != nil {
+			return candidate{}, "", false, err
+		}
+		if strings.TrimSpace(message) == "" {
+			return candidate{}, "", false, nil
+		}
+	}
+	return chosen, message, true, nil
+}
+
+// editMessage opens the message in the user's editor; comment lines are dropped as git does
+func editMessage(message string) (string, error) {
+	f, err := os.CreateTemp("", "gommit-edit-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("could not create message file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(message + "\n\n# Edit the commit message. Lines starting with '#' are ignored; an empty message cancels.\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not write message file: %w", err)
+	}
+
+	if err := setup.EditFile(f.Name()); err != nil {
+		return "", fmt.Errorf("editor failed: %w", err)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("could not read edited message: %w", err)
+	}
+	return strings.TrimSpace(lint.StripComments(string(data))), nil
+}
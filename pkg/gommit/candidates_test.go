@@ -0,0 +1,65 @@
+package gommit
+
+import (
+	"testing"
+
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// TestPlanCandidates checks the order of candidate sources and that unknown providers fail in isolation.
+func TestPlanCandidates(t *testing.T) {
+	detailed := 0.2
+	cfg := &types.Config{
+		CommitStyle: "conventional",
+		Providers: map[string]types.ProviderConfig{
+			"openai":    {Model: "gpt-4o-mini", Temperature: 0.7},
+			"anthropic": {Model: "claude-3-5-haiku-latest"},
+		},
+		Candidates: []types.CandidateConfig{{CommitStyle: "detailed", Temperature: &detailed}},
+	}
+	selected := cfg.Providers["openai"]
+	selected.Model = "gpt-4o"
+
+	plan := planCandidates(cfg, "openai", selected, 5, []string{"anthropic", "mistral:large"})
+
+	want := []string{
+		"openai/gpt-4o, t=1.0 (fixed), conventional",
+		"anthropic/claude-3-5-haiku-latest, t=0.0, conventional",
+		"mistral/large, t=0.0, ",
+		"openai/gpt-4o, t=1.0 (fixed), detailed",
+		"openai/gpt-4o, t=1.0 (fixed), conventional",
+	}
+	if len(plan) != len(want) {
+		t.Fatalf("planCandidates() returned %d candidates, want %d", len(plan), len(want))
+	}
+	for i, w := range want {
+		if got := plan[i].Label(); got != w {
+			t.Errorf("candidate %d = %q, want %q", i, got, w)
+		}
+	}
+	if plan[2].Err == nil {
+		t.Errorf("unconfigured provider should fail without affecting the others")
+	}
+}
+
+// TestPlanCandidates_Temperatures checks that the extra candidates spread the temperature of models
+// that accept one.
+func TestPlanCandidates_Temperatures(t *testing.T) {
+	cfg := &types.Config{
+		CommitStyle: "simple",
+		Providers:   map[string]types.ProviderConfig{"anthropic": {Model: "claude-3-5-haiku-latest", Temperature: 0.7}},
+	}
+
+	plan := planCandidates(cfg, "anthropic", cfg.Providers["anthropic"], 3, nil)
+
+	want := []string{
+		"anthropic/claude-3-5-haiku-latest, t=0.7, simple",
+		"anthropic/claude-3-5-haiku-latest, t=0.3, simple",
+		"anthropic/claude-3-5-haiku-latest, t=0.9, simple",
+	}
+	for i, w := range want {
+		if got := plan[i].Label(); got != w {
+			t.Errorf("candidate %d = %q, want %q", i, got, w)
+		}
+	}
+}
@@ -0,0 +1,418 @@
+package gommit
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/edhuardotierrez/gommit/internal/audit"
+	"github.com/edhuardotierrez/gommit/internal/colors"
+	"github.com/edhuardotierrez/gommit/internal/config"
+	"github.com/edhuardotierrez/gommit/internal/env"
+	"github.com/edhuardotierrez/gommit/internal/git"
+	"github.com/edhuardotierrez/gommit/internal/hook"
+	"github.com/edhuardotierrez/gommit/internal/llm"
+	"github.com/edhuardotierrez/gommit/internal/setup"
+	"github.com/edhuardotierrez/gommit/internal/types"
+	"github.com/edhuardotierrez/gommit/internal/usage"
+)
+
+// command is a gommit subcommand. Setup registers the command's flags on fs and returns the function
+// that parses args and runs it, returning the process exit code.
+type command struct {
+	Name    string
+	Usage   []string
+	Summary string
+	Hidden  bool
+	Notes   func() string
+	Setup   func(fs *flag.FlagSet) func(args []string) int
+}
+
+// commands lists the subcommands in the order they are shown in the help; the first one is the default
+var commands []command
+
+func init() {
+	commands = []command{
+		{
+			Name:    "commit",
+			Usage:   []string{"commit [flags] [-- <git commit options>] [-- <paths>]"},
+			Summary: "Generate a message for the staged changes and commit them (default)",
+			Setup:   commitCommand,
+		},
+		{
+			Name: "config",
+			Usage: []string{
+				"config wizard|edit|provider|defaults|schema",
+				"config get|unset <key>",
+				"config set <key> <value>",
+				"config list [-show-secrets]",
+				"config show [-effective] [-show-secrets]",
+				"config provider add <name> [-model m] [-api-key k] [-uri u] [-temperature t] [-commit-style s] [-default]",
+				"config provider remove|set-default <name>",
+			},
+			Summary: "Create or edit the configuration file",
+			Notes: func() string {
+				return "Subcommands:\n" +
+					"  wizard     Run the full configuration wizard\n" +
+					"  edit       Open the configuration file in $EDITOR\n" +
+					"  provider   Add or update a provider (interactive without arguments)\n" +
+					"  defaults   Edit the default provider, commit style and limits\n" +
+					"  schema     Print the JSON Schema of the configuration file\n" +
+					"  get        Print the value of a key (exit code 1 when it is not set)\n" +
+					"  set        Set a key; values are checked against the schema\n" +
+					"  unset      Remove a key so its default applies\n" +
+					"  list       Print every value set in the file as key=value\n" +
+					"  show       Print the config file, or with -effective the configuration merged from\n" +
+					"             the system, shared and user config, where each value comes from and\n" +
+					"             which keys are locked\n" +
+					"\nKeys are dotted paths such as commit_style or providers.ollama.uri; arrays take a\n" +
+					"comma-separated list or JSON, e.g. gommit config set lint.types feat,fix,docs\n\n" +
+					formatKeys() +
+					"\nConfig file: " + config.GetConfigPath() + "\n" +
+					"System config: " + config.SystemConfigPath() + "\n"
+			},
+			Setup: configCommand,
+		},
+		{
+			Name:    "doctor",
+			Usage:   []string{"doctor [flags]"},
+			Summary: "Check git, the configuration, the providers and the hooks",
+			Setup:   doctorCommand,
+		},
+		{
+			Name:    "hook",
+			Usage:   []string{"hook install|uninstall|status [flags]"},
+			Summary: "Manage the commit-msg hook that runs gommit lint",
+			Setup:   hookCommand,
+		},
+		{
+			Name:    "lint",
+			Usage:   []string{"lint [flags] [file|-]", "lint [flags] --range main..HEAD"},
+			Summary: "Check commit messages against the commit rules",
+			Setup:   lintCommand,
+		},
+		{
+			Name:    "models",
+			Usage:   []string{"models [provider]"},
+			Summary: "List the models available for each provider",
+			Setup:   modelsCommand,
+		},
+		{
+			Name:    "stats",
+			Usage:   []string{"stats [flags]"},
+			Summary: "Show token usage, latency and estimated cost",
+			Notes:   func() string { return "Usage log: " + usage.LogPath() + "\n" },
+			Setup:   statsCommand,
+		},
+		{
+			Name:    "audit",
+			Usage:   []string{"audit show [flags]"},
+			Summary: "Show what was sent to which provider",
+			Notes: func() string {
+				return "The audit log is off by default; enable it with `gommit config set audit.enabled true`.\n" +
+					"Audit log: " + audit.LogPath() + "\n"
+			},
+			Setup: auditCommand,
+		},
+		{
+			Name:    "version",
+			Usage:   []string{"version"},
+			Summary: "Show version information",
+			Setup:   versionCommand,
+		},
+		{
+			Name:    "completion",
+			Usage:   []string{"completion bash|zsh|fish"},
+			Summary: "Print a shell completion script",
+			Notes: func() string {
+				return "Examples:\n" +
+					"  source <(gommit completion bash)\n" +
+					"  gommit completion zsh > \"${fpath[1]}/_gommit\"\n" +
+					"  gommit completion fish > ~/.config/fish/completions/gommit.fish\n"
+			},
+			Setup: completionCommand,
+		},
+		{
+			Name:   "__complete",
+			Usage:  []string{"__complete providers|models [provider]"},
+			Hidden: true,
+			Setup:  completeCommand,
+		},
+	}
+}
+
+// findCommand returns the subcommand with the given name
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// newFlagSet registers the command's flags and help on a new flag set
+func (c command) newFlagSet() (*flag.FlagSet, func(args []string) int) {
+	fs := flag.NewFlagSet("gommit "+c.Name, flag.ExitOnError)
+	run := c.Setup(fs)
+	fs.Usage = func() { c.printHelp(fs) }
+	return fs, run
+}
+
+// printHelp writes the command's usage, flags and notes to stderr
+func (c command) printHelp(fs *flag.FlagSet) {
+	fmt.Fprintf(os.Stderr, "Usage:\n")
+	for _, u := range c.Usage {
+		fmt.Fprintf(os.Stderr, "  gommit %s\n", u)
+	}
+	if c.Summary != "" {
+		fmt.Fprintf(os.Stderr, "\n%s\n", c.Summary)
+	}
+
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintf(os.Stderr, "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if c.Notes != nil {
+		fmt.Fprintf(os.Stderr, "\n%s", c.Notes())
+	}
+}
+
+// printUsage writes the top-level help listing every subcommand
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage:\n")
+	fmt.Fprintf(os.Stderr, "  gommit [flags] [-- <git commit options>] [-- <paths>]\n")
+	fmt.Fprintf(os.Stderr, "  gommit <command> [flags] [args]\n\nCommands:\n")
+	for _, c := range commands {
+		if !c.Hidden {
+			fmt.Fprintf(os.Stderr, "  %-12s %s\n", c.Name, c.Summary)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'gommit help <command>' for the flags of a command.\n")
+}
+
+// Run dispatches the command line to a subcommand. Without a command (or when the first argument is
+// a flag) the default commit command runs, so `gommit -p openai` keeps working.
+func Run() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	name := "commit"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	// Flags of the former flag-only interface
+	if len(args) > 0 && name == "commit" {
+		switch args[0] {
+		case "-version", "--version":
+			name, args = "version", args[1:]
+		case "-config", "--config":
+			name, args = "config", args[1:]
+		case "-h", "-help", "--help":
+			printUsage()
+			return 0
+		}
+	}
+
+	if name == "help" {
+		if len(args) == 0 {
+			printUsage()
+			return 0
+		}
+		c, ok := findCommand(args[0])
+		if !ok {
+			colors.ErrorOutput("Error: unknown command %q\n", args[0])
+			return 1
+		}
+		fs, _ := c.newFlagSet()
+		c.printHelp(fs)
+		return 0
+	}
+
+	c, ok := findCommand(name)
+	if !ok {
+		colors.ErrorOutput("Error: unknown command %q\n\n", name)
+		printUsage()
+		return 1
+	}
+	_, runCommand := c.newFlagSet()
+	return runCommand(args)
+}
+
+// configCommand implements `gommit config <wizard|edit|provider|defaults|schema|get|set|unset|list|show>`
+func configCommand(fs *flag.FlagSet) func(args []string) int {
+	return func(args []string) int {
+		_ = fs.Parse(args)
+		if fs.NArg() == 0 {
+			fs.Usage()
+			return 1
+		}
+
+		switch fs.Arg(0) {
+		case "wizard":
+			if _, err := setup.CreateConfigWizard(config.GetConfigPath()); err != nil {
+				colors.ErrorOutput("Error in configuration wizard: %v\n", err)
+				return 1
+			}
+			colors.SuccessOutput("\nConfiguration completed successfully!\n\n")
+		case "edit":
+			if err := setup.EditConfigInEditor(config.GetConfigPath()); err != nil {
+				colors.ErrorOutput("Error opening editor: %v\n", err)
+				return 1
+			}
+			colors.SuccessOutput("\nConfig file edited.\n\n")
+		case "get":
+			return configGet(fs.Args()[1:])
+		case "set":
+			return configSet(fs.Args()[1:])
+		case "unset":
+			return configUnset(fs.Args()[1:])
+		case "list":
+			return configList(fs.Args()[1:])
+		case "show":
+			return configShow(fs.Args()[1:])
+		case "provider":
+			if fs.NArg() > 1 {
+				return configProvider(fs.Args()[1:])
+			}
+			if err := setup.EditProviderWizard(config.GetConfigPath()); err != nil {
+				colors.ErrorOutput("Error editing provider: %v\n", err)
+				return 1
+			}
+			colors.SuccessOutput("\nProvider updated successfully!\n\n")
+		case "defaults":
+			if err := setup.EditDefaultsWizard(config.GetConfigPath()); err != nil {
+				colors.ErrorOutput("Error editing defaults: %v\n", err)
+				return 1
+			}
+			colors.SuccessOutput("\nDefaults updated successfully!\n\n")
+		case "schema":
+			_, _ = os.Stdout.Write(config.Schema)
+		default:
+			colors.ErrorOutput("Error: invalid config subcommand %q (expected: wizard|edit|provider|defaults|schema|get|set|unset|list|show)\n", fs.Arg(0))
+			return 1
+		}
+		return 0
+	}
+}
+
+// hookCommand implements `gommit hook <install|uninstall|status>`
+func hookCommand(fs *flag.FlagSet) func(args []string) int {
+	force := fs.Bool("force", false, "Replace an existing commit-msg hook (the old one is kept as .bak)")
+
+	return func(args []string) int {
+		_ = fs.Parse(args)
+		if fs.NArg() == 0 {
+			fs.Usage()
+			return 1
+		}
+
+		switch fs.Arg(0) {
+		case "install":
+			return runHookInstaller(true, *force)
+		case "uninstall":
+			return runHookInstaller(false, false)
+		case "status":
+			if !git.IsGitRepository() {
+				colors.ErrorOutput("Error: not a git repository\n")
+				return 1
+			}
+			status, path, err := hook.GetStatus(hook.CommitMsg)
+			if err != nil {
+				colors.ErrorOutput("Error: %v\n", err)
+				return 1
+			}
+			colors.TextOutput("%s: %s (%s)\n", hook.CommitMsg, status, path)
+			return 0
+		default:
+			colors.ErrorOutput("Error: invalid hook subcommand %q (expected: install|uninstall|status)\n", fs.Arg(0))
+			return 1
+		}
+	}
+}
+
+// runHookInstaller installs or removes the commit-msg hook in the current repository
+func runHookInstaller(install, force bool) int {
+	if !git.IsGitRepository() {
+		colors.ErrorOutput("Error: not a git repository\n")
+		return 1
+	}
+
+	if install {
+		path, err := hook.Install(hook.CommitMsg, force)
+		if err != nil {
+			colors.ErrorOutput("Error installing hook: %v\n", err)
+			return 1
+		}
+		colors.SuccessOutput("✅ Installed commit-msg hook at %s\n", path)
+		return 0
+	}
+
+	path, err := hook.Uninstall(hook.CommitMsg)
+	if err != nil {
+		colors.ErrorOutput("Error removing hook: %v\n", err)
+		return 1
+	}
+	colors.SuccessOutput("✅ Removed commit-msg hook at %s\n", path)
+	return 0
+}
+
+// modelsCommand implements `gommit models [provider]`: the models each provider offers to the
+// configured credentials, falling back to the built-in lists offline
+func modelsCommand(fs *flag.FlagSet) func(args []string) int {
+	refresh := fs.Bool("refresh", false, "Ask the providers again instead of using the cached lists")
+
+	return func(args []string) int {
+		_ = fs.Parse(args)
+		env.LoadFile()
+		cfg, err := config.Read()
+		if err != nil {
+			colors.ErrorOutput("Error loading configuration: %v\n", err)
+			return 1
+		}
+
+		var providers []types.ProviderName
+		if fs.NArg() > 0 {
+			providers = append(providers, types.ProviderName(fs.Arg(0)))
+		} else {
+			for _, p := range llm.Providers {
+				providers = append(providers, types.ProviderName(p.Title))
+			}
+		}
+
+		for _, p := range providers {
+			if _, ok := llm.ProviderByTitle(string(p)); !ok {
+				colors.ErrorOutput("Error: unknown provider %q\n", p)
+				return 1
+			}
+			list := llm.ListModels(p, llm.WithEnvCredentials(p, cfg.Providers[string(p)]), *refresh)
+			if list.Err != nil {
+				colors.WarningOutput("⚠️ Could not list %s models (%v), showing the built-in list\n", p, list.Err)
+			}
+
+			if len(providers) == 1 {
+				colors.TextOutput("%s\n", strings.Join(list.Models, "\n"))
+				continue
+			}
+			colors.InfoOutput("%s (%s):\n", p, list.Source)
+			for _, m := range list.Models {
+				colors.TextOutput("  %s\n", m)
+			}
+		}
+		return 0
+	}
+}
+
+// versionCommand implements `gommit version`
+func versionCommand(fs *flag.FlagSet) func(args []string) int {
+	return func(args []string) int {
+		_ = fs.Parse(args)
+		fmt.Printf("gommit version %s\n", version)
+		return 0
+	}
+}
@@ -0,0 +1,161 @@
+package gommit
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/edhuardotierrez/gommit/internal/config"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// TestPassThroughArgs checks how arguments after `--` are split between git commit options and paths.
+func TestPassThroughArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		options []string
+		paths   []string
+		wantErr bool
+	}{
+		{args: []string{"-p", "openai"}},
+		{args: []string{"-p", "openai", "--", "--no-verify"}, options: []string{"--no-verify"}},
+		{args: []string{"--", "main.go", "go.mod"}, paths: []string{"main.go", "go.mod"}},
+		{args: []string{"--", "--no-verify", "--", "main.go"}, options: []string{"--no-verify"}, paths: []string{"main.go"}},
+		{args: []string{"main.go"}, wantErr: true},
+		{args: []string{"--", "-m", "message"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("commit", flag.ContinueOnError)
+		fs.String("p", "", "")
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.args, err)
+		}
+
+		options, paths, err := passThroughArgs(fs, tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("passThroughArgs(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+		}
+		if !slices.Equal(options, tt.options) || !slices.Equal(paths, tt.paths) {
+			t.Fatalf("passThroughArgs(%q) = %q, %q, want %q, %q", tt.args, options, paths, tt.options, tt.paths)
+		}
+	}
+}
+
+// TestCompletionScripts checks that every shell script offers the commands and their flags.
+func TestCompletionScripts(t *testing.T) {
+	writers := map[string]func(*bytes.Buffer){
+		"bash": func(b *bytes.Buffer) { writeBashCompletion(b) },
+		"zsh":  func(b *bytes.Buffer) { writeZshCompletion(b) },
+		"fish": func(b *bytes.Buffer) { writeFishCompletion(b) },
+	}
+
+	for shell, write := range writers {
+		var b bytes.Buffer
+		write(&b)
+		script := b.String()
+
+		for _, want := range []string{"commit", "config", "hook", "lint", "models", "stats", "version", "interactive-stage", "__complete providers", "__complete models"} {
+			if !strings.Contains(script, want) {
+				t.Errorf("%s completion does not mention %q", shell, want)
+			}
+		}
+		if strings.Contains(script, "__complete)") || strings.Contains(script, "-a __complete") {
+			t.Errorf("%s completion offers the hidden __complete command", shell)
+		}
+	}
+}
+
+// TestConfigCommands drives the non-interactive config commands the way a bootstrap script would.
+func TestConfigCommands(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GOMMIT_SYSTEM_CONFIG", filepath.Join(home, "none.json"))
+
+	for _, args := range [][]string{
+		{"config", "provider", "add", "ollama", "-uri", "http://localhost:11434", "-model", "llama3", "-default"},
+		{"config", "provider", "add", "openai", "-model", "gpt-4o-mini", "-api-key", "sk-test"},
+		{"config", "set", "commit_style", "simple"},
+		{"config", "set", "lint.types", "feat,fix"},
+		{"config", "unset", "lint.types"},
+		{"config", "provider", "set-default", "openai"},
+		{"config", "provider", "remove", "ollama"},
+	} {
+		if code := run(args); code != 0 {
+			t.Fatalf("gommit %s: exit code %d", strings.Join(args, " "), code)
+		}
+	}
+	for _, args := range [][]string{
+		{"config", "set", "commit_style", "simpel"},
+		{"config", "provider", "add", "anthropic", "-model", "claude-sonnet-4-0"}, // no api key
+		{"config", "provider", "remove", "google"},                                // not configured
+		{"config", "get", "max_tokens"},                                           // not set
+	} {
+		if code := run(args); code != 1 {
+			t.Errorf("gommit %s: exit code %d, want 1", strings.Join(args, " "), code)
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load() failed: %v", err)
+	}
+	want := map[string]types.ProviderConfig{"openai": {APIKey: "sk-test", Model: "gpt-4o-mini", Temperature: types.DefaultTemperature}}
+	if cfg.DefaultProvider != "openai" || cfg.CommitStyle != "simple" || !reflect.DeepEqual(cfg.Providers, want) || cfg.Lint.Types != nil {
+		t.Errorf("config after the commands: %+v", cfg)
+	}
+}
+
+// TestConfigLocked checks that keys locked by the system config cannot be changed from the command line.
+func TestConfigLocked(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GOMMIT_SYSTEM_CONFIG", filepath.Join(home, "system.json"))
+	t.Chdir(home)
+	system := `{"default_provider": "ollama", "providers": {"ollama": {"uri": "http://localhost:11434", "model": "llama3"}}, "locked": ["default_provider", "providers.ollama"]}`
+	if err := os.WriteFile(filepath.Join(home, "system.json"), []byte(system), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"config", "set", "commit_style", "simple"},
+		{"config", "show", "-effective"},
+	} {
+		if code := run(args); code != 0 {
+			t.Fatalf("gommit %s: exit code %d", strings.Join(args, " "), code)
+		}
+	}
+	for _, args := range [][]string{
+		{"config", "set", "default_provider", "openai"},
+		{"config", "set", "providers.ollama.model", "mistral"},
+		{"config", "provider", "remove", "ollama"},
+		{"config", "set", "locked", "commit_style"},
+	} {
+		if code := run(args); code != 1 {
+			t.Errorf("gommit %s: exit code %d, want 1", strings.Join(args, " "), code)
+		}
+	}
+}
+
+// TestLintHandWritten checks that a hand-written message with a long body is not held to the length
+// budget of generated messages.
+func TestLintHandWritten(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GOMMIT_SYSTEM_CONFIG", filepath.Join(home, "none.json"))
+	t.Chdir(home)
+
+	body := strings.Repeat("Explain the change in enough detail for the reviewers to follow it.\n", 12)
+	path := filepath.Join(home, "COMMIT_EDITMSG")
+	if err := os.WriteFile(path, []byte("feat: add the parser\n\n"+body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code := run([]string{"lint", path}); code != 0 {
+		t.Errorf("gommit lint of a long hand-written message: exit code %d, want 0", code)
+	}
+}
@@ -0,0 +1,135 @@
+package gommit
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+
+	"github.com/edhuardotierrez/gommit/internal/cache"
+	"github.com/edhuardotierrez/gommit/internal/colors"
+	"github.com/edhuardotierrez/gommit/internal/git"
+	"github.com/edhuardotierrez/gommit/internal/lint"
+)
+
+// passThroughArgs returns what follows `--` on the command line: options forwarded to `git commit`
+// and paths to limit the commit to. A second `--` separates the two; otherwise the arguments are
+// options when the first one starts with "-", and paths when it does not. Any other positional
+// argument is an error.
+func passThroughArgs(fs *flag.FlagSet, args []string) (options, paths []string, err error) {
+	rest := fs.Args()
+	if len(rest) == 0 {
+		return nil, nil, nil
+	}
+	// flag consumes the "--" terminator, so look for it right before the remaining args
+	if i := len(args) - len(rest) - 1; i < 0 || args[i] != "--" {
+		return nil, nil, fmt.Errorf("invalid argument %q (use -- to forward options or paths to git commit)", rest[0])
+	}
+
+	if i := slices.Index(rest, "--"); i >= 0 {
+		options, paths = rest[:i], rest[i+1:]
+	} else if strings.HasPrefix(rest[0], "-") {
+		options = rest
+	} else {
+		paths = rest
+	}
+
+	if err := git.ValidateCommitArgs(options); err != nil {
+		return nil, nil, err
+	}
+	return options, paths, nil
+}
+
+// commitWithRetry creates the commit; when git or one of its hooks fails, the user may retry with the same message (e.g. after fixing what a pre-commit hook reported)
+func commitWithRetry(opts git.CommitOptions) error {
+	for {
+		colors.InfoOutput("\n⏳ Creating git commit...\n")
+		err := git.Commit(opts)
+
+		var commitErr *git.CommitError
+		if err == nil || !errors.As(err, &commitErr) {
+			return err
+		}
+
+		// the output of git and its hooks was streamed above
+		colors.ErrorOutput("\n❌ git commit failed (%v)\n\n", commitErr.Err)
+
+		prompt := promptui.Prompt{
+			Label:     "🔁 Retry the commit with the same message",
+			IsConfirm: true,
+		}
+		if _, promptErr := prompt.Run(); promptErr != nil {
+			return err
+		}
+	}
+}
+
+// hasArg reports whether a forwarded git option is present, alone or as --name=value
+func hasArg(args []string, name string) bool {
+	return slices.ContainsFunc(args, func(a string) bool {
+		return a == name || strings.HasPrefix(a, name+"=")
+	})
+}
+
+// previewMessage prints a commit message with its title and any unrepaired rule violations
+func previewMessage(title, message string, warnings []lint.Violation) {
+	colors.InfoOutput(title)
+	colors.InfoOutput(strings.Repeat("-", len(title)) + "\n")
+	fmt.Println(message)
+	colors.InfoOutput("\n---------------------------------------------------------------\n")
+
+	if len(warnings) > 0 {
+		colors.WarningOutput("⚠️ The message still breaks some commit rules:\n%s\n", lint.FormatViolations(warnings))
+	}
+}
+
+// rememberMessage keeps the message for `gommit -retry`; failing to do so is not fatal
+func rememberMessage(message, provider, model string) {
+	tree, _ := git.WriteTree()
+	_ = cache.SaveLast(cache.Entry{Tree: tree, Provider: provider, Model: model, Message: message})
+}
+
+// retryLastMessage commits the remembered message after confirmation and returns the exit code
+func retryLastMessage(opts git.CommitOptions) int {
+	last, err := cache.Last()
+	if err != nil {
+		colors.ErrorOutput("Error reading the last message: %v\n", err)
+		return 1
+	}
+	if last == nil {
+		colors.ErrorOutput("❌ No message to retry: the last one was committed or none was generated yet\n")
+		return 1
+	}
+
+	if tree, err := git.WriteTree(); err == nil && tree != last.Tree && !opts.All && len(opts.Paths) == 0 {
+		colors.WarningOutput("⚠️ The staged changes differ from the ones this message was generated for\n")
+	}
+
+	previewMessage(fmt.Sprintf("\n🔁 Last commit message (%s, %s):\n", last.Model, last.CreatedAt.Format("2006-01-02 15:04")), last.Message, nil)
+
+	prompt := promptui.Prompt{Label: "✨ Would you like to proceed with this commit message", IsConfirm: true}
+	if _, err := prompt.Run(); err != nil {
+		colors.InfoOutput("\n🚫 Commit cancelled by user\n")
+		return 0
+	}
+
+	return commitMessage(opts, last.Message, last.Provider, last.Model)
+}
+
+// commitMessage creates the commit, remembering the message until it succeeds, and returns the exit code
+func commitMessage(opts git.CommitOptions, message, provider, model string) int {
+	rememberMessage(message, provider, model)
+	opts.Message = message
+	if err := commitWithRetry(opts); err != nil {
+		colors.ErrorOutput("❌ Error creating commit: %v\n\n", err)
+		colors.DescOutput("Run `gommit -retry` to commit the same message once the problem is fixed.\n")
+		return 1
+	}
+	cache.ClearLast()
+
+	colors.SuccessOutput("\n✅ Successfully created commit!\n\n")
+	return 0
+}
@@ -0,0 +1,338 @@
+package gommit
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/edhuardotierrez/gommit/internal/colors"
+	"github.com/edhuardotierrez/gommit/internal/config"
+	"github.com/edhuardotierrez/gommit/internal/llm"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// completionArgs are the positional arguments completed for each command; "$providers" is replaced
+// by the configured providers at completion time
+var completionArgs = map[string][]string{
+	"config":     {"wizard", "edit", "provider", "defaults", "schema", "get", "set", "unset", "list", "show"},
+	"hook":       {"install", "uninstall", "status"},
+	"audit":      {"show"},
+	"models":     {"$providers"},
+	"completion": {"bash", "zsh", "fish"},
+}
+
+// commitStyles are completed for the -s flag
+var commitStyles = config.CommitStyles
+
+// completionFlag is a flag of a command, as needed by the completion scripts
+type completionFlag struct {
+	Name  string
+	Usage string
+	Bool  bool
+}
+
+// commandFlags returns the flags a command registers, in the order flag.VisitAll reports them
+func commandFlags(c command) []completionFlag {
+	fs, _ := c.newFlagSet()
+	var flags []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{Name: f.Name, Usage: f.Usage, Bool: ok && b.IsBoolFlag()})
+	})
+	return flags
+}
+
+// visibleCommands returns the names of the commands offered for completion
+func visibleCommands() []string {
+	var names []string
+	for _, c := range commands {
+		if !c.Hidden {
+			names = append(names, c.Name)
+		}
+	}
+	return append(names, "help")
+}
+
+// completionCommand implements `gommit completion bash|zsh|fish`
+func completionCommand(fs *flag.FlagSet) func(args []string) int {
+	return func(args []string) int {
+		_ = fs.Parse(args)
+		if fs.NArg() != 1 {
+			fs.Usage()
+			return 1
+		}
+
+		switch fs.Arg(0) {
+		case "bash":
+			writeBashCompletion(os.Stdout)
+		case "zsh":
+			writeZshCompletion(os.Stdout)
+		case "fish":
+			writeFishCompletion(os.Stdout)
+		default:
+			colors.ErrorOutput("Error: unsupported shell %q (expected: bash|zsh|fish)\n", fs.Arg(0))
+			return 1
+		}
+		return 0
+	}
+}
+
+// completeCommand implements the hidden `gommit __complete` used by the completion scripts for
+// values that depend on the user's configuration. It never fails loudly: a broken config simply
+// completes nothing.
+func completeCommand(fs *flag.FlagSet) func(args []string) int {
+	return func(args []string) int {
+		_ = fs.Parse(args)
+		cfg, err := config.Read()
+		if err != nil {
+			cfg = &types.Config{}
+		}
+
+		switch fs.Arg(0) {
+		case "providers":
+			for _, p := range completionProviders(cfg) {
+				fmt.Println(p)
+			}
+		case "models":
+			provider := fs.Arg(1)
+			if provider == "" {
+				provider = cfg.DefaultProvider
+			}
+			for _, m := range completionModels(cfg, provider) {
+				fmt.Println(m)
+			}
+		}
+		return 0
+	}
+}
+
+// completionProviders returns the configured providers, or every supported one when none is configured
+func completionProviders(cfg *types.Config) []string {
+	var providers []string
+	for name := range cfg.Providers {
+		providers = append(providers, name)
+	}
+	if len(providers) == 0 {
+		for _, p := range llm.Providers {
+			providers = append(providers, p.Title)
+		}
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// completionModels returns the models of a provider (cached for llm.ModelsTTL), starting with the
+// configured one
+func completionModels(cfg *types.Config, provider string) []string {
+	var models []string
+	if m := cfg.Providers[provider].Model; m != "" {
+		models = append(models, m)
+	}
+	name := types.ProviderName(provider)
+	for _, m := range llm.ListModels(name, llm.WithEnvCredentials(name, cfg.Providers[provider]), false).Models {
+		if !slices.Contains(models, m) {
+			models = append(models, m)
+		}
+	}
+	return models
+}
+
+// flagNames returns the flags of a command as they are typed on the command line
+func flagNames(flags []completionFlag) string {
+	var names []string
+	for _, f := range flags {
+		names = append(names, "-"+f.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+// staticArgs returns the positional arguments of a command that do not need gommit to be completed
+func staticArgs(name string) string {
+	var args []string
+	for _, a := range completionArgs[name] {
+		if !strings.HasPrefix(a, "$") {
+			args = append(args, a)
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprintf(w, `# bash completion for gommit
+_gommit() {
+    local cur prev cmd words i provider
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    cmd=commit
+    if [[ $COMP_CWORD -gt 1 && ${COMP_WORDS[1]} != -* ]]; then
+        cmd="${COMP_WORDS[1]}"
+    fi
+
+    case "$prev" in
+        -p|--p|-candidate|--candidate)
+            COMPREPLY=($(compgen -W "$(gommit __complete providers 2>/dev/null)" -- "$cur"))
+            return ;;
+        -m|--m)
+            provider=""
+            for ((i = 1; i < COMP_CWORD - 1; i++)); do
+                if [[ ${COMP_WORDS[i]} == -p || ${COMP_WORDS[i]} == --p ]]; then
+                    provider="${COMP_WORDS[i+1]}"
+                fi
+            done
+            COMPREPLY=($(compgen -W "$(gommit __complete models $provider 2>/dev/null)" -- "$cur"))
+            return ;;
+        -s|--s)
+            COMPREPLY=($(compgen -W "%s" -- "$cur"))
+            return ;;
+    esac
+
+    if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then
+        COMPREPLY=($(compgen -W "%s" -- "$cur"))
+        return
+    fi
+
+    case "$cmd" in
+`, strings.Join(commitStyles, " "), strings.Join(visibleCommands(), " "))
+
+	for _, c := range commands {
+		if c.Hidden {
+			continue
+		}
+		words := strings.TrimSpace(flagNames(commandFlags(c)) + " " + staticArgs(c.Name))
+		if slices.Contains(completionArgs[c.Name], "$providers") {
+			words += ` $(gommit __complete providers 2>/dev/null)`
+		}
+		fmt.Fprintf(w, "        %s) words=\"%s\" ;;\n", c.Name, words)
+	}
+	fmt.Fprintf(w, `        help) words="%s" ;;
+        *) words="" ;;
+    esac
+    COMPREPLY=($(compgen -W "$words" -- "$cur"))
+}
+complete -o default -F _gommit gommit
+`, strings.Join(visibleCommands(), " "))
+}
+
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprintf(w, `#compdef gommit
+# zsh completion for gommit
+_gommit() {
+    local cmd=commit prev=${words[CURRENT-1]} cur=${words[CURRENT]} provider i
+    if (( CURRENT > 2 )) && [[ ${words[2]} != -* ]]; then
+        cmd=${words[2]}
+    fi
+
+    case $prev in
+        -p|--p|-candidate|--candidate)
+            compadd -- ${(f)"$(gommit __complete providers 2>/dev/null)"}
+            return ;;
+        -m|--m)
+            i=${words[(I)-p]}
+            (( i )) && provider=${words[i+1]}
+            compadd -- ${(f)"$(gommit __complete models $provider 2>/dev/null)"}
+            return ;;
+        -s|--s)
+            compadd -- %s
+            return ;;
+    esac
+
+    if (( CURRENT == 2 )) && [[ $cur != -* ]]; then
+        compadd -- %s
+        return
+    fi
+
+    case $cmd in
+`, strings.Join(commitStyles, " "), strings.Join(visibleCommands(), " "))
+
+	for _, c := range commands {
+		if c.Hidden {
+			continue
+		}
+		words := strings.TrimSpace(flagNames(commandFlags(c)) + " " + staticArgs(c.Name))
+		if slices.Contains(completionArgs[c.Name], "$providers") {
+			words += ` ${(f)"$(gommit __complete providers 2>/dev/null)"}`
+		}
+		fmt.Fprintf(w, "        %s) compadd -- %s ;;\n", c.Name, words)
+	}
+	fmt.Fprintf(w, `        help) compadd -- %s ;;
+    esac
+}
+
+if [ "$funcstack[1]" = "_gommit" ]; then
+    _gommit "$@"
+else
+    compdef _gommit gommit
+fi
+`, strings.Join(visibleCommands(), " "))
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprint(w, `# fish completion for gommit
+function __gommit_command
+    set -l tokens (commandline -opc)
+    if test (count $tokens) -gt 1; and not string match -q -- '-*' $tokens[2]
+        echo $tokens[2]
+    else
+        echo commit
+    end
+end
+
+function __gommit_using
+    test (__gommit_command) = $argv[1]
+end
+
+function __gommit_provider
+    set -l tokens (commandline -opc)
+    if set -l i (contains -i -- -p $tokens)
+        echo $tokens[(math $i + 1)]
+    end
+end
+
+complete -c gommit -f
+`)
+
+	for _, c := range commands {
+		if c.Hidden {
+			continue
+		}
+		fmt.Fprintf(w, "complete -c gommit -n __fish_use_subcommand -a %s -d %s\n", c.Name, fishQuote(c.Summary))
+	}
+	fmt.Fprintf(w, "complete -c gommit -n __fish_use_subcommand -a help -d %s\n", fishQuote("Show the help of a command"))
+	fmt.Fprintf(w, "complete -c gommit -n '__gommit_using help' -a %s\n", fishQuote(strings.Join(visibleCommands(), " ")))
+
+	for _, c := range commands {
+		if c.Hidden {
+			continue
+		}
+		cond := fishQuote("__gommit_using " + c.Name)
+		for _, f := range commandFlags(c) {
+			line := fmt.Sprintf("complete -c gommit -n %s -o %s -d %s", cond, f.Name, fishQuote(f.Usage))
+			switch {
+			case f.Name == "p" || f.Name == "candidate":
+				line += " -x -a '(gommit __complete providers 2>/dev/null)'"
+			case f.Name == "m":
+				line += " -x -a '(gommit __complete models (__gommit_provider) 2>/dev/null)'"
+			case f.Name == "s":
+				line += " -x -a " + fishQuote(strings.Join(commitStyles, " "))
+			case !f.Bool:
+				line += " -r"
+			}
+			fmt.Fprintln(w, line)
+		}
+		if args := staticArgs(c.Name); args != "" {
+			fmt.Fprintf(w, "complete -c gommit -n %s -a %s\n", cond, fishQuote(args))
+		}
+		if slices.Contains(completionArgs[c.Name], "$providers") {
+			fmt.Fprintf(w, "complete -c gommit -n %s -a '(gommit __complete providers 2>/dev/null)'\n", cond)
+		}
+	}
+}
+
+// fishQuote quotes s as a single-quoted fish string
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,280 @@
+package gommit
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/edhuardotierrez/gommit/internal/colors"
+	"github.com/edhuardotierrez/gommit/internal/config"
+	"github.com/edhuardotierrez/gommit/internal/git"
+	"github.com/edhuardotierrez/gommit/internal/hook"
+	"github.com/edhuardotierrez/gommit/internal/llm"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// minGitVersion is the oldest git gommit is tested with
+const minGitVersion = "1.8.5"
+
+// doctor prints the outcome of each check and remembers whether any failed
+type doctor struct {
+	failed bool
+}
+
+func (d *doctor) section(title string) {
+	colors.InfoOutput("\n%s\n", title)
+}
+
+func (d *doctor) ok(format string, args ...any) {
+	colors.SuccessOutput("  ✅ %s\n", fmt.Sprintf(format, args...))
+}
+
+func (d *doctor) warn(format string, args ...any) {
+	colors.WarningOutput("  ⚠️ %s\n", fmt.Sprintf(format, args...))
+}
+
+func (d *doctor) fail(format string, args ...any) {
+	d.failed = true
+	colors.ErrorOutput("  ❌ %s\n", fmt.Sprintf(format, args...))
+}
+
+// doctorCommand implements `gommit doctor`: git, repository, configuration, provider and hook checks.
+// It returns 1 when any check fails; warnings do not change the exit code.
+func doctorCommand(fs *flag.FlagSet) func(args []string) int {
+	offline := fs.Bool("offline", false, "Skip the provider checks, which call each provider's API")
+	only := fs.String("p", "", "Only check this provider")
+
+	return func(args []string) int {
+		_ = fs.Parse(args)
+		d := &doctor{}
+
+		d.section("Git")
+		inRepo := d.checkGit()
+
+		d.section("Configuration")
+		cfg := d.checkConfig()
+
+		if cfg != nil && !*offline {
+			d.section("Providers")
+			d.checkProviders(cfg, *only)
+		}
+
+		if inRepo {
+			d.section("Hooks")
+			d.checkHooks()
+		}
+
+		fmt.Println()
+		if d.failed {
+			colors.ErrorOutput("Some checks failed.\n")
+			return 1
+		}
+		colors.SuccessOutput("Everything looks good.\n")
+		return 0
+	}
+}
+
+// checkGit reports the git version and the state of the current repository; it returns whether
+// the working directory is inside a repository
+func (d *doctor) checkGit() bool {
+	version, err := git.GetVersion()
+	switch {
+	case err != nil:
+		d.fail("git is not available: %v", err)
+		return false
+	case compareVersions(version, minGitVersion) < 0:
+		d.fail("git %s is older than %s", version, minGitVersion)
+	default:
+		d.ok("git %s", version)
+	}
+
+	if !git.IsGitRepository() {
+		d.warn("not inside a git repository, repository checks skipped")
+		return false
+	}
+	d.ok("repository %s", git.GetRootPath())
+
+	if branch := git.GetCurrentBranch(); branch != "" {
+		d.ok("on branch %s", branch)
+	} else {
+		d.warn("HEAD is detached")
+	}
+	if op := git.GetOperationInProgress(); op != "" {
+		d.warn("a %s is in progress", op)
+	}
+
+	changes, err := git.GetStagedChanges()
+	switch {
+	case err != nil:
+		d.fail("could not read the staged changes: %v", err)
+	case len(changes) == 0:
+		d.ok("no staged changes")
+	default:
+		d.ok("%d staged file(s)", len(changes))
+	}
+	return true
+}
+
+// checkConfig reads and validates the configuration; it returns nil when it cannot be used. With a
+// system config, the merged configuration is checked instead of the user file alone.
+func (d *doctor) checkConfig() *types.Config {
+	if _, err := os.Stat(config.SystemConfigPath()); err == nil {
+		return d.checkLayers()
+	}
+	cfg := d.checkConfigFile(config.GetConfigPath())
+	if cfg != nil && cfg.Shared.Source != "" {
+		return d.checkLayers()
+	}
+	return cfg
+}
+
+// checkLayers loads the system, shared and user config and reports the locked values they ignore
+func (d *doctor) checkLayers() *types.Config {
+	eff, err := config.LoadEffective(true)
+	var invalid *config.InvalidError
+	switch {
+	case errors.As(err, &invalid):
+		for _, p := range invalid.Problems {
+			d.fail("%s: %s", invalid.Path, p)
+		}
+		return nil
+	case errors.Is(err, config.ErrNotFound):
+		d.fail("no configuration found (run `gommit config wizard`)")
+		return nil
+	case err != nil:
+		d.fail("%v", err)
+		return nil
+	}
+
+	for _, layer := range eff.Layers {
+		d.ok("%s config %s", layer.Name, layer.Path)
+	}
+	if _, err := os.Stat(config.GetConfigPath()); err != nil {
+		d.ok("no user config, the system config applies")
+	}
+	for _, w := range eff.Warnings {
+		d.warn("%s", w)
+	}
+	for _, ig := range eff.Ignored {
+		d.warn("%s config sets %s, which the %s config locked; it is ignored", ig.Layer, ig.Key, ig.LockedBy)
+	}
+	cfg := eff.Config
+	d.ok("default provider %s, commit style %s", cmp.Or(cfg.DefaultProvider, "openai"), cmp.Or(cfg.CommitStyle, types.DefaultCommitStyle))
+	return cfg
+}
+
+// checkConfigFile reads and validates one configuration file
+func (d *doctor) checkConfigFile(path string) *types.Config {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		d.fail("%s does not exist (run `gommit config wizard`)", path)
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		d.fail("could not read %s: %v", path, err)
+		return nil
+	}
+	cfg, problems, err := config.CheckFile(path, data)
+	if err != nil {
+		d.fail("%s: %v", path, err)
+		return nil
+	}
+	d.ok("%s", path)
+
+	failed := false
+	for _, p := range problems {
+		if p.Warning {
+			d.warn("%s (ignored)", p)
+			continue
+		}
+		d.fail("%s", p)
+		failed = true
+	}
+	if cfg == nil {
+		return nil
+	}
+	if !failed {
+		d.ok("default provider %s, commit style %s", cmp.Or(cfg.DefaultProvider, "openai"), cmp.Or(cfg.CommitStyle, types.DefaultCommitStyle))
+	}
+	return cfg
+}
+
+// checkProviders makes an authenticated call to each configured provider and checks its model
+func (d *doctor) checkProviders(cfg *types.Config, only string) {
+	names := make([]string, 0, len(cfg.Providers))
+	for name := range cfg.Providers {
+		if only == "" || name == only {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		d.fail("no provider to check")
+		return
+	}
+
+	for _, name := range names {
+		pc := cfg.Providers[name]
+		label := name
+		if name == cfg.DefaultProvider {
+			label += " (default)"
+		}
+
+		check, err := llm.CheckProvider(types.ProviderName(name), pc)
+		if err != nil {
+			d.fail("%s: %v", label, err)
+			continue
+		}
+		d.ok("%s: model %s available (%d models, %dms)", label, pc.Model, check.Models, check.Latency.Milliseconds())
+	}
+}
+
+// checkHooks reports whether gommit's commit-msg hook is installed
+func (d *doctor) checkHooks() {
+	status, path, err := hook.GetStatus(hook.CommitMsg)
+	switch {
+	case err != nil:
+		d.fail("%s: %v", hook.CommitMsg, err)
+	case status == hook.StatusForeign:
+		d.warn("%s: %s at %s (`gommit hook install --force` replaces it)", hook.CommitMsg, status, path)
+	case status == hook.StatusMissing:
+		d.ok("%s: %s (optional, `gommit hook install`)", hook.CommitMsg, status)
+	default:
+		d.ok("%s: %s at %s", hook.CommitMsg, status, path)
+	}
+}
+
+// compareVersions compares dotted version numbers, ignoring suffixes like ".windows.1" or "-rc1"
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < 3; i++ {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) [3]int {
+	var parts [3]int
+	for i, field := range strings.SplitN(v, ".", 4) {
+		if i == 3 {
+			break
+		}
+		digits := field
+		if end := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+			digits = digits[:end]
+		}
+		parts[i], _ = strconv.Atoi(digits)
+	}
+	return parts
+}
@@ -0,0 +1,61 @@
+package gommit
+
+import (
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestDoctor runs the checks in a fresh repository against a local Ollama stand-in.
+func TestDoctor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"models":[{"name":"llama3:latest"}]}`))
+	}))
+	defer server.Close()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GOMMIT_SYSTEM_CONFIG", filepath.Join(home, "none.json"))
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	if out, err := exec.Command("git", "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+
+	doctorWith := func(model string) int {
+		cfg := `{"default_provider": "ollama", "providers": {"ollama": {"uri": "` + server.URL + `", "model": "` + model + `"}}}`
+		if err := os.WriteFile(filepath.Join(home, "gommit.json"), []byte(cfg), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return doctorCommand(flag.NewFlagSet("doctor", flag.ContinueOnError))(nil)
+	}
+
+	if code := doctorWith("llama3"); code != 0 {
+		t.Fatalf("doctor exit code = %d, want 0", code)
+	}
+	if code := doctorWith("mistral"); code != 1 {
+		t.Fatalf("doctor exit code = %d with a missing model, want 1", code)
+	}
+}
+
+// TestCompareVersions checks git version comparisons, including platform suffixes.
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"2.43.0", "1.8.5", 1},
+		{"1.8.5", "1.8.5", 0},
+		{"1.8.4.windows.1", "1.8.5", -1},
+		{"2.39.3 (Apple Git-145)", "2.39.3", 0},
+		{"2.45.0-rc1", "2.44.0", 1},
+	} {
+		if got := compareVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package gommit
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/edhuardotierrez/gommit/internal/colors"
+	"github.com/edhuardotierrez/gommit/internal/config"
+	"github.com/edhuardotierrez/gommit/internal/git"
+	"github.com/edhuardotierrez/gommit/internal/lint"
+	"github.com/edhuardotierrez/gommit/internal/rules"
+	"github.com/edhuardotierrez/gommit/internal/ticket"
+)
+
+// lintResult is the outcome of linting a single message
+type lintResult struct {
+	Source     string           `json:"source"`
+	Subject    string           `json:"subject"`
+	Violations []lint.Violation `json:"violations"`
+}
+
+// lintCommand implements `gommit lint [file|-]` and `gommit lint --range <rev-range>`. It returns the
+// process exit code: 1 when any message breaks the rules.
+func lintCommand(fs *flag.FlagSet) func(args []string) int {
+	revisionRange := fs.String("range", "", "Lint every commit in a revision range (e.g. main..HEAD)")
+	format := fs.String("format", "text", "Output format: text|json")
+	style := fs.String("s", "", "Commit style to validate against (default: from config)")
+	fix := fs.Bool("fix", false, "Rewrite the message file with automatic repairs applied")
+
+	return func(args []string) int {
+		_ = fs.Parse(args)
+
+		if *format != "text" && *format != "json" {
+			colors.ErrorOutput("Error: invalid --format %q (expected: text|json)\n", *format)
+			return 1
+		}
+
+		cfg, err := config.Read()
+		if err != nil {
+			colors.ErrorOutput("Error loading configuration: %v\n", err)
+			return 1
+		}
+		if *style != "" {
+			cfg.CommitStyle = *style
+		}
+		// Hand-written messages: no length budget and no markdown check, which are for generated ones
+		options := lint.OptionsFromConfig(cfg, cfg.CommitStyle, 0)
+
+		repoRules, err := rules.Load()
+		if err != nil {
+			colors.ErrorOutput("Error loading rules: %v\n", err)
+			return 1
+		}
+		repoRules.DropLocked(cfg.IsLocked)
+		repoRules.ApplyLint(&options)
+
+		var results []lintResult
+		switch {
+		case *revisionRange != "":
+			commits, err := git.GetCommitMessages(*revisionRange)
+			if err != nil {
+				colors.ErrorOutput("Error: %v\n", err)
+				return 1
+			}
+			for _, c := range commits {
+				results = append(results, lintMessage(c.Hash[:min(len(c.Hash), 12)], c.Message, options))
+			}
+
+		default:
+			source := fs.Arg(0)
+			message, err := readMessage(source)
+			if err != nil {
+				colors.ErrorOutput("Error: %v\n", err)
+				fs.Usage()
+				return 1
+			}
+
+			message = lint.StripComments(message)
+
+			// A message being written now must reference the ticket of the current branch
+			branchTicket, _, err := ticket.FromBranch(cfg.Ticket)
+			if err != nil {
+				colors.ErrorOutput("Error: %v\n", err)
+				return 1
+			}
+			options.Ticket = branchTicket
+
+			if *fix && source != "" && source != "-" {
+				fixed, _ := lint.Fix(message, options)
+				if fixed != message && !lint.Ignored(message) {
+					if err := os.WriteFile(source, []byte(fixed+"\n"), 0o644); err != nil {
+						colors.ErrorOutput("Error writing %s: %v\n", source, err)
+						return 1
+					}
+					message = fixed
+				}
+			}
+
+			if source == "" {
+				source = "-"
+			}
+			results = append(results, lintMessage(source, message, options))
+		}
+
+		failed := false
+		for _, r := range results {
+			if len(r.Violations) > 0 {
+				failed = true
+			}
+		}
+
+		if *format == "json" {
+			out, _ := json.MarshalIndent(results, "", "  ")
+			fmt.Println(string(out))
+		} else {
+			printLintResults(results)
+		}
+
+		if failed {
+			return 1
+		}
+		return 0
+	}
+}
+
+// lintMessage checks one message; merges, reverts and fixups are skipped
+func lintMessage(source, message string, options lint.Options) lintResult {
+	subject, _, _ := strings.Cut(message, "\n")
+	result := lintResult{Source: source, Subject: subject, Violations: []lint.Violation{}}
+	if lint.Ignored(message) {
+		return result
+	}
+	if v := lint.Check(message, options); v != nil {
+		result.Violations = v
+	}
+	return result
+}
+
+// readMessage reads a commit message from a file, or from stdin for "-" (or no argument when piped)
+func readMessage(source string) (string, error) {
+	if source != "" && source != "-" {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return "", fmt.Errorf("could not read message file: %w", err)
+		}
+		return string(data), nil
+	}
+
+	if source == "" {
+		if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+			return "", fmt.Errorf("no message given: pass a file, '-' for stdin, or --range")
+		}
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("could not read message from stdin: %w", err)
+	}
+	return string(data), nil
+}
+
+func printLintResults(results []lintResult) {
+	for _, r := range results {
+		if len(r.Violations) == 0 {
+			colors.SuccessOutput("✅ %s: %s\n", r.Source, r.Subject)
+			continue
+		}
+		colors.ErrorOutput("❌ %s: %s\n", r.Source, r.Subject)
+		for _, v := range r.Violations {
+			hint := ""
+			if v.Fixable {
+				hint = " [fixable]"
+			}
+			colors.TextOutput("   - %s%s\n", v, hint)
+		}
+	}
+}
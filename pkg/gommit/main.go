@@ -1,10 +1,10 @@
 package gommit
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"math/rand"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -18,243 +18,321 @@ import (
 	"github.com/edhuardotierrez/gommit/internal/globals"
 	"github.com/edhuardotierrez/gommit/internal/llm"
 	"github.com/edhuardotierrez/gommit/internal/setup"
+	"github.com/edhuardotierrez/gommit/internal/trailer"
 )
 
 var (
 	version = "dev" // This will be overridden during build
 )
 
-func Run() {
-	// Add flags
-	showVersion := flag.Bool("version", false, "Show version information")
-	runConfig := flag.Bool("config", false, "Run configuration tools (subcommands: wizard|edit|provider)")
-	showVerbose := flag.Bool("verbose", false, "Show verbose output")
+// commitCommand is the default command: it generates a message for the staged changes and commits it
+func commitCommand(fs *flag.FlagSet) func(args []string) int {
+	showVerbose := fs.Bool("verbose", false, "Show verbose output")
 
 	// optional
-	runWithProvider := flag.String("p", "", "Run with a specific provider (optional)")
-	runWithModel := flag.String("m", "", "Run with a specific model (optional)")
-	runWithTemperature := flag.String("t", "", "Run with a specific temperature (optional)")
-	runWithStyle := flag.String("s", "", "Run with a specific commit style (optional)")
-	runWithTruncateLines := flag.Int("l", 0, "Run with a specific number of truncate lines (optional)")
-	runWithMaxLineWidth := flag.Int("w", 0, "Run with a specific max line width (optional)")
-
-	// Custom usage message
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage of gommit:\n")
-		fmt.Fprintf(os.Stderr, "  gommit [flags]\n\nFlags:\n")
-		flag.PrintDefaults()
-	}
+	runWithProvider := fs.String("p", "", "Run with a specific provider (optional)")
+	runWithModel := fs.String("m", "", "Run with a specific model (optional)")
+	runWithTemperature := fs.String("t", "", "Run with a specific temperature (optional)")
+	runWithStyle := fs.String("s", "", "Run with a specific commit style (optional)")
+	runWithTruncateLines := fs.Int("l", 0, "Run with a specific number of truncate lines (optional)")
+	runWithMaxLineWidth := fs.Int("w", 0, "Run with a specific max line width (optional)")
+
+	// trailers and signing
+	var coAuthors stringList
+	fs.Var(&coAuthors, "co-author", "Add a Co-authored-by trailer: \"Name <email>\" or part of a recent author's name (repeatable)")
+	pickCoAuthorsFlag := fs.Bool("pick-co-authors", false, "Pick co-authors from recent commit authors")
+	signoff := fs.Bool("signoff", false, "Add a Signed-off-by trailer (git commit --signoff)")
+	gpgSign := fs.Bool("S", false, "GPG-sign the commit (git commit -S)")
+
+	// candidates
+	candidateCount := fs.Int("n", 1, "Generate several candidate messages concurrently and choose one")
+	var candidateProviders stringList
+	fs.Var(&candidateProviders, "candidate", "Add a candidate from another provider: \"provider\" or \"provider:model\" (repeatable)")
+
+	// cache
+	refresh := fs.Bool("refresh", false, "Ignore the cached message for these changes and generate a new one")
+	retryLast := fs.Bool("retry", false, "Commit the last generated message again, without calling the AI")
+
+	// staging
+	commitAll := fs.Bool("a", false, "Commit all tracked modifications, like git commit -a")
+	stageInteractively := fs.Bool("interactive-stage", false, "Pick unstaged and untracked files to stage before generating")
+
+	return func(args []string) int {
+		_ = fs.Parse(args)
+
+		// Only args after `--` are accepted; they are forwarded to git commit
+		commitArgs, commitPaths, err := passThroughArgs(fs, args)
+		if err != nil {
+			colors.ErrorOutput("Error: %v\n", err)
+			fs.Usage()
+			return 1
+		}
+		if *commitAll && len(commitPaths) > 0 {
+			colors.ErrorOutput("Error: -a cannot be combined with paths\n")
+			return 1
+		}
 
-	// Parse and validate flags
-	flag.Parse()
-
-	// Handle configuration flows early (supporting subcommands)
-	if *runConfig {
-		args := flag.Args()
-		if len(args) == 0 {
-			colors.ErrorOutput("Error: -config requires a subcommand (wizard|edit|provider)\n")
-			os.Exit(1)
-		} else if args[0] == "wizard" {
-			_, err := setup.CreateConfigWizard(config.GetConfigPath())
-			if err != nil {
-				colors.ErrorOutput("Error in configuration wizard: %v\n", err)
-				os.Exit(1)
-			}
-			colors.SuccessOutput("\nConfiguration completed successfully!\n\n")
-			return
+		if *showVerbose {
+			globals.VerboseMode = true
 		}
 
-		switch args[0] {
-		case "edit":
-			if err := setup.EditConfigInEditor(config.GetConfigPath()); err != nil {
-				colors.ErrorOutput("Error opening editor: %v\n", err)
-				os.Exit(1)
-			}
-			colors.SuccessOutput("\nConfig file edited.\n\n")
-			return
-		case "provider":
-			if err := setup.EditProviderWizard(config.GetConfigPath()); err != nil {
-				colors.ErrorOutput("Error editing provider: %v\n", err)
-				os.Exit(1)
+		// Load configuration
+		cfg, err := config.Load()
+		if errors.Is(err, config.ErrNotFound) {
+			_, _ = setup.CreateConfigWizard(config.GetConfigPath())
+			fmt.Printf("\n🚀 You're all set! Run 'gommit' to start using gommit.\n")
+			return 0
+		}
+		if err != nil {
+			colors.ErrorOutput("Error loading configuration: %v\n", err)
+			return 1
+		}
+
+		// Check if we're in a git repository
+		if !git.IsGitRepository() {
+			colors.ErrorOutput("Error: not a git repository\n")
+			return 1
+		}
+
+		commitOptions := git.CommitOptions{
+			Signoff:   *signoff || cfg.Signoff,
+			GPGSign:   *gpgSign,
+			All:       *commitAll,
+			Paths:     commitPaths,
+			ExtraArgs: commitArgs,
+		}
+
+		// git builds fixup messages itself, there is nothing to generate
+		if hasArg(commitArgs, "--fixup") {
+			if err := commitWithRetry(commitOptions); err != nil {
+				colors.ErrorOutput("❌ Error creating commit: %v\n\n", err)
+				return 1
 			}
-			colors.SuccessOutput("\nProvider updated successfully!\n\n")
-			return
-		case "defaults":
-			if err := setup.EditDefaultsWizard(config.GetConfigPath()); err != nil {
-				colors.ErrorOutput("Error editing defaults: %v\n", err)
-				os.Exit(1)
+			colors.SuccessOutput("\n✅ Successfully created commit!\n\n")
+			return 0
+		}
+
+		// Commit the remembered message of a failed or cancelled attempt
+		if *retryLast {
+			return retryLastMessage(commitOptions)
+		}
+
+		if *stageInteractively {
+			staged, err := interactiveStage()
+			if err != nil {
+				colors.ErrorOutput("Error staging files: %v\n", err)
+				return 1
 			}
-			colors.SuccessOutput("\nDefaults updated successfully!\n\n")
-			return
-		default:
-			colors.ErrorOutput("Error: invalid -config subcommand %q (expected: wizard|edit|provider)\n", args[0])
-			flag.Usage()
-			os.Exit(1)
+			colors.InfoOutput("📥 Staged %d file(s)\n\n", staged)
 		}
-	}
 
-	// Check for invalid trailing args when not using -config
-	if flag.NArg() > 0 {
-		colors.ErrorOutput("Error: invalid argument %q\n", flag.Arg(0))
-		flag.Usage()
-		os.Exit(1)
-	}
+		// Get the changes the commit will record
+		s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+		s.Suffix = " Analyzing git changes..."
+		_ = s.Color("cyan")
+		s.Start()
 
-	if *showVersion {
-		fmt.Printf("gommit version %s", version)
-		return
-	}
+		changes, err := git.GetCommitChanges(*commitAll, commitPaths)
+		s.Stop()
+		if err != nil {
+			colors.ErrorOutput("Error getting staged changes: %v\n", err)
+			return 1
+		}
 
-	if *showVerbose {
-		globals.VerboseMode = true
-	}
+		if len(changes) == 0 && !hasArg(commitArgs, "--allow-empty") {
+			// Get list of modified but unstaged files
+			unstagedFiles, err := git.GetUnstagedChanges()
+			if err != nil {
+				colors.ErrorOutput("Error getting unstaged changes: %v\n", err)
+				return 1
+			}
 
-	// (handled above) -config
+			colors.ErrorOutput("\n❌ No staged changes found. Use 'git add' first.\n\n")
 
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		colors.ErrorOutput("Error loading configuration: %v\n", err)
-		os.Exit(1)
-	}
+			if len(unstagedFiles) > 0 {
+				colors.DescOutput("Modified files that could be staged:\n")
+				colors.DescOutput("----------------------------------\n")
 
-	// Check if we're in a git repository
-	if !git.IsGitRepository() {
-		colors.ErrorOutput("Error: not a git repository\n")
-		os.Exit(1)
-	}
+				// Show up to 10 unstaged files
+				maxFiles := 10
+				if len(unstagedFiles) < maxFiles {
+					maxFiles = len(unstagedFiles)
+				}
 
-	// Get staged changes
-	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
-	s.Suffix = " Analyzing git changes..."
-	_ = s.Color("cyan")
-	s.Start()
-
-	changes, err := git.GetStagedChanges()
-	s.Stop()
-	if err != nil {
-		colors.ErrorOutput("Error getting staged changes: %v\n", err)
-		os.Exit(1)
-	}
+				for i := 0; i < maxFiles; i++ {
+					colors.TextOutput("  • %s (%s)\n", unstagedFiles[i].Path, unstagedFiles[i].Status)
+				}
 
-	if len(changes) == 0 {
-		// Get list of modified but unstaged files
-		unstagedFiles, err := git.GetUnstagedChanges()
-		if err != nil {
-			colors.ErrorOutput("Error getting unstaged changes: %v\n", err)
-			os.Exit(1)
+				if len(unstagedFiles) > maxFiles {
+					colors.DescOutput("\nAnd %d more files...\n", len(unstagedFiles)-maxFiles)
+				}
+
+				colors.DescOutput("\nTry: git add <file> to stage specific files\n")
+				colors.DescOutput("  or: git add . to stage all files\n")
+				colors.DescOutput("  or: gommit -a to commit all tracked modifications\n")
+				colors.DescOutput("  or: gommit --interactive-stage to pick the files to stage\n")
+			}
+
+			return 0
 		}
 
-		colors.ErrorOutput("\n❌ No staged changes found. Use 'git add' first.\n\n")
+		var provider = cfg.DefaultProvider
+		var overrides []string
 
-		if len(unstagedFiles) > 0 {
-			colors.DescOutput("Modified files that could be staged:\n")
-			colors.DescOutput("----------------------------------\n")
+		if *runWithProvider != "" {
+			provider = *runWithProvider
+			overrides = append(overrides, provider)
+		}
 
-			// Show up to 10 unstaged files
-			maxFiles := 10
-			if len(unstagedFiles) < maxFiles {
-				maxFiles = len(unstagedFiles)
+		// Command-line overrides cannot change what the system or shared config locked
+		for _, o := range []struct {
+			flag, key string
+			set       bool
+		}{
+			{"-p", "default_provider", *runWithProvider != ""},
+			{"-m", "providers." + provider + ".model", *runWithModel != ""},
+			{"-t", "providers." + provider + ".temperature", *runWithTemperature != ""},
+			{"-s", "commit_style", *runWithStyle != ""},
+			{"-l", "truncate_lines", *runWithTruncateLines > 0},
+			{"-w", "max_line_width", *runWithMaxLineWidth > 0},
+		} {
+			if o.set && cfg.IsLocked(o.key) {
+				colors.ErrorOutput("Error: %s cannot be used, %s is locked by the system configuration\n", o.flag, o.key)
+				return 1
 			}
+		}
 
-			for i := 0; i < maxFiles; i++ {
-				colors.TextOutput("  • %s (%s)\n", unstagedFiles[i].Path, unstagedFiles[i].Status)
-			}
+		selectedConfig := cfg.Providers[provider]
 
-			if len(unstagedFiles) > maxFiles {
-				colors.DescOutput("\nAnd %d more files...\n", len(unstagedFiles)-maxFiles)
-			}
+		// Add model and temperature if provided
+		if *runWithModel != "" {
+			selectedConfig.Model = *runWithModel
+			overrides = append(overrides, fmt.Sprintf("model(%s)", *runWithModel))
+		}
 
-			colors.DescOutput("\nTry: git add <file> to stage specific files\n")
-			colors.DescOutput("  or: git add . to stage all files\n")
+		// if flagTemperature is not 0, set the temperature
+		runWithTemperatureFloat, err := strconv.ParseFloat(*runWithTemperature, 64)
+		if err == nil && runWithTemperatureFloat >= 0.0 {
+			selectedConfig.Temperature = runWithTemperatureFloat
+			overrides = append(overrides, fmt.Sprintf("temperature(%.2f)", runWithTemperatureFloat))
 		}
 
-		os.Exit(0)
-	}
+		if *runWithStyle != "" {
+			selectedConfig.CommitStyle = *runWithStyle
+			overrides = append(overrides, fmt.Sprintf("style(%s)", *runWithStyle))
+		}
 
-	var provider = cfg.DefaultProvider
-	var overrides []string
+		if *runWithTruncateLines > 0 {
+			cfg.TruncateLines = *runWithTruncateLines
+			overrides = append(overrides, fmt.Sprintf("truncate_lines(%d)", *runWithTruncateLines))
+		}
 
-	if *runWithProvider != "" {
-		provider = *runWithProvider
-		overrides = append(overrides, provider)
-	}
+		if *runWithMaxLineWidth > 0 {
+			cfg.MaxLineWidth = *runWithMaxLineWidth
+			overrides = append(overrides, fmt.Sprintf("max_line_width(%d)", *runWithMaxLineWidth))
+		}
 
-	selectedConfig := cfg.Providers[provider]
+		if len(overrides) > 0 {
+			colors.WarningOutput("⚠️ Overriding configuration: %s\n\n", strings.Join(overrides, ", "))
+		}
 
-	// Add model and temperature if provided
-	if *runWithModel != "" {
-		selectedConfig.Model = *runWithModel
-		overrides = append(overrides, fmt.Sprintf("model(%s)", *runWithModel))
-	}
+		// Resolve trailers before generating so the picker does not interrupt the preview
+		trailers, err := collectTrailers(cfg, coAuthors, *pickCoAuthorsFlag)
+		if err != nil {
+			colors.ErrorOutput("Error: %v\n", err)
+			return 1
+		}
 
-	// if flagTemperature is not 0, set the temperature
-	runWithTemperatureFloat, err := strconv.ParseFloat(*runWithTemperature, 64)
-	if err == nil && runWithTemperatureFloat >= 0.0 {
-		selectedConfig.Temperature = runWithTemperatureFloat
-		overrides = append(overrides, fmt.Sprintf("temperature(%.2f)", runWithTemperatureFloat))
-	}
+		globals.RefreshCache = *refresh
 
-	if *runWithStyle != "" {
-		selectedConfig.CommitStyle = *runWithStyle
-		overrides = append(overrides, fmt.Sprintf("style(%s)", *runWithStyle))
-	}
+		// Several candidates: generate them concurrently and let the user choose (and edit) one
+		if *candidateCount > 1 || len(candidateProviders) > 0 {
+			plan := planCandidates(cfg, provider, selectedConfig, *candidateCount, candidateProviders)
+			s.Suffix = fmt.Sprintf(" Generating %d commit message candidates using AI...", len(plan))
+			s.Start()
+			candidates, err := generateCandidates(cfg, changes, plan)
+			s.Stop()
+			if err != nil {
+				colors.ErrorOutput("Error generating commit message: %v\n", err)
+				return 1
+			}
 
-	if *runWithTruncateLines > 0 {
-		cfg.TruncateLines = *runWithTruncateLines
-		overrides = append(overrides, fmt.Sprintf("truncate_lines(%d)", *runWithTruncateLines))
-	}
+			for _, c := range candidates {
+				if c.Err != nil {
+					colors.WarningOutput("⚠️ Candidate %s failed: %v\n", c.Label(), c.Err)
+				}
+			}
 
-	if *runWithMaxLineWidth > 0 {
-		cfg.MaxLineWidth = *runWithMaxLineWidth
-		overrides = append(overrides, fmt.Sprintf("max_line_width(%d)", *runWithMaxLineWidth))
-	}
+			chosen, message, ok, err := selectCandidate(candidates, trailers)
+			if err != nil {
+				colors.ErrorOutput("Error: %v\n", err)
+				return 1
+			}
+			if !ok {
+				colors.InfoOutput("\n🚫 Commit cancelled by user\n")
+				return 0
+			}
+			return commitMessage(commitOptions, message, chosen.Provider, chosen.Config.Model)
+		}
 
-	if len(overrides) > 0 {
-		colors.WarningOutput("⚠️ Overriding configuration: %s\n\n", strings.Join(overrides, ", "))
-	}
+		var message string
+		for {
+			// Generate commit message using LLM
+			s.Suffix = fmt.Sprintf(" Generating commit message using AI (%s)...", selectedConfig.Model)
+			s.Start()
+			result, err := llm.GenerateCommitMessage(cfg, changes, provider, selectedConfig)
+			s.Stop()
+			if err != nil {
+				colors.ErrorOutput("Error generating commit message: %v\n", err)
+				return 1
+			}
 
-	// Generate commit message using LLM
-	s.Suffix = fmt.Sprintf(" Generating commit message using AI (%s)...", selectedConfig.Model)
-	s.Start()
-	message, err := llm.GenerateCommitMessage(cfg, changes, provider, selectedConfig)
-	s.Stop()
-	if err != nil {
-		colors.ErrorOutput("Error generating commit message: %v\n", err)
-		os.Exit(1)
-	}
+			// Trailers are appended deterministically, never left to the model
+			message = trailer.Append(result.Message, trailers...)
 
-	// Preview commit message and ask for confirmation
-	randIcons := []string{"✍️", "✏️", "📝", "💡", "🧠"}
-	title := fmt.Sprintf("\n%s Generated commit message (%s):\n", randIcons[rand.Intn(len(randIcons))], selectedConfig.Model)
-	colors.InfoOutput(title)
-	colors.InfoOutput(strings.Repeat("-", len(title)) + "\n")
-	fmt.Println(message)
-	colors.InfoOutput("\n---------------------------------------------------------------\n")
-
-	labelConfirmation := "✨ Would you like to proceed with this commit message"
-	colors.InfoOutput(labelConfirmation)
-	colors.InfoOutput(strings.Repeat("-", len(labelConfirmation)))
-
-	prompt := promptui.Prompt{
-		Label:     labelConfirmation,
-		IsConfirm: true,
-	}
+			// Preview commit message and ask for confirmation
+			randIcons := []string{"✍️", "✏️", "📝", "💡", "🧠"}
+			title := fmt.Sprintf("\n%s Generated commit message (%s):\n", randIcons[rand.Intn(len(randIcons))], selectedConfig.Model)
+			if result.Cached {
+				title = fmt.Sprintf("\n♻️ Cached commit message (%s, %s):\n", selectedConfig.Model, result.CachedAt.Format("2006-01-02 15:04"))
+			}
+			previewMessage(title, message, result.Warnings)
 
-	if _, err := prompt.Run(); err != nil {
-		colors.InfoOutput("\n🚫 Commit cancelled by user\n")
-		os.Exit(0)
-	}
+			if !result.Cached {
+				break
+			}
 
-	// Create the commit
-	s.Suffix = " Creating git commit..."
-	s.Start()
-	err = git.Commit(message)
-	s.Stop()
-	if err != nil {
-		colors.ErrorOutput("❌ Error creating commit: %v\n\n", err)
-		os.Exit(1)
-	}
+			// A cached message is offered first; the user may ask for a fresh one
+			choice := promptui.Select{
+				Label: "✨ This message was generated earlier for the same changes",
+				Items: []string{"Use this message", "Generate a new message", "Cancel"},
+			}
+			idx, _, err := choice.Run()
+			if err != nil || idx == 2 {
+				colors.InfoOutput("\n🚫 Commit cancelled by user\n")
+				return 0
+			}
+			if idx == 0 {
+				break
+			}
+			globals.RefreshCache = true
+		}
 
-	colors.SuccessOutput("\n✅ Successfully created commit!\n\n")
+		labelConfirmation := "✨ Would you like to proceed with this commit message"
+		colors.InfoOutput(labelConfirmation)
+		colors.InfoOutput(strings.Repeat("-", len(labelConfirmation)))
+
+		prompt := promptui.Prompt{
+			Label:     labelConfirmation,
+			IsConfirm: true,
+		}
+
+		if _, err := prompt.Run(); err != nil {
+			rememberMessage(message, provider, selectedConfig.Model)
+			colors.InfoOutput("\n🚫 Commit cancelled by user (run `gommit -retry` to use this message later)\n")
+			return 0
+		}
+
+		return commitMessage(commitOptions, message, provider, selectedConfig.Model)
+	}
 }
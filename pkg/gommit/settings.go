@@ -0,0 +1,372 @@
+package gommit
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/edhuardotierrez/gommit/internal/colors"
+	"github.com/edhuardotierrez/gommit/internal/config"
+	"github.com/edhuardotierrez/gommit/internal/llm"
+	"github.com/edhuardotierrez/gommit/internal/rules"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// configSubcommand parses the flags of a `gommit config <name>` subcommand
+func configSubcommand(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet("gommit config "+name, flag.ContinueOnError)
+	fs.Usage = func() {
+		colors.TextOutput("Usage: gommit config %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// readSettings loads the configuration file as written; a missing file has no settings
+func readSettings() (*types.Config, error) {
+	cfg, err := config.LoadFile(config.GetConfigPath())
+	if errors.Is(err, config.ErrNotFound) {
+		return &types.Config{}, nil
+	}
+	return cfg, err
+}
+
+// configGet implements `gommit config get <key>`; like git config, it exits with 1 when the key is not set
+func configGet(args []string) int {
+	if len(args) != 1 {
+		colors.ErrorOutput("Usage: gommit config get <key>\n")
+		return 2
+	}
+	raw, err := config.ReadSettings(config.GetConfigPath())
+	if errors.Is(err, config.ErrNotFound) {
+		raw, err = map[string]any{}, nil
+	}
+	if err != nil {
+		colors.ErrorOutput("Error loading configuration: %v\n", err)
+		return 2
+	}
+	value, ok, err := config.GetValue(raw, args[0])
+	if err != nil {
+		colors.ErrorOutput("Error: %v\n", err)
+		return 2
+	}
+	if !ok {
+		return 1
+	}
+	colors.TextOutput("%s\n", config.Setting{Key: args[0], Value: value})
+	return 0
+}
+
+// configSet implements `gommit config set <key> <value>`
+func configSet(args []string) int {
+	if len(args) != 2 {
+		colors.ErrorOutput("Usage: gommit config set <key> <value>\n")
+		return 1
+	}
+	err := checkUnlocked(args[0])
+	if err == nil {
+		err = config.Edit(config.GetConfigPath(), func(raw map[string]any) error {
+			return config.SetValue(raw, args[0], args[1])
+		})
+	}
+	if err != nil {
+		colors.ErrorOutput("Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// configUnset implements `gommit config unset <key>`
+func configUnset(args []string) int {
+	if len(args) != 1 {
+		colors.ErrorOutput("Usage: gommit config unset <key>\n")
+		return 1
+	}
+	err := checkUnlocked(args[0])
+	if err == nil {
+		err = config.Edit(config.GetConfigPath(), func(raw map[string]any) error {
+			return config.UnsetValue(raw, args[0])
+		})
+	}
+	if err != nil {
+		colors.ErrorOutput("Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// configList implements `gommit config list`: every value set in the file as key=value
+func configList(args []string) int {
+	fs := configSubcommand("list", "list [flags]")
+	showSecrets := fs.Bool("show-secrets", false, "Show API keys instead of masking them")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	cfg, err := readSettings()
+	if err != nil {
+		colors.ErrorOutput("Error loading configuration: %v\n", err)
+		return 1
+	}
+	settings, err := config.Settings(cfg)
+	if err != nil {
+		colors.ErrorOutput("Error: %v\n", err)
+		return 1
+	}
+	for _, s := range settings {
+		colors.TextOutput("%s=%s\n", s.Key, displayValue(s, *showSecrets))
+	}
+	return 0
+}
+
+// maskSecret keeps the last four characters of a secret
+func maskSecret(s string) string {
+	if len(s) <= 8 {
+		return strings.Repeat("*", len(s))
+	}
+	return strings.Repeat("*", 8) + s[len(s)-4:]
+}
+
+// configProvider implements `gommit config provider add|remove|set-default`
+func configProvider(args []string) int {
+	usage := "Usage: gommit config provider add <name> [flags] | remove <name> | set-default <name>\n"
+	if len(args) < 2 {
+		colors.ErrorOutput(usage)
+		return 1
+	}
+	action, name := args[0], args[1]
+	if _, ok := llm.ProviderByTitle(name); !ok {
+		colors.ErrorOutput("Error: unknown provider %q\n", name)
+		return 1
+	}
+
+	var change func(raw map[string]any) error
+	switch action {
+	case "add":
+		fs := configSubcommand("provider add", "provider add <name> [flags]")
+		values := map[string]*string{
+			"model":        fs.String("model", "", "Model to use"),
+			"api_key":      fs.String("api-key", "", "API key"),
+			"uri":          fs.String("uri", "", "Base URL of the API"),
+			"temperature":  fs.String("temperature", "", "Temperature (0-1)"),
+			"commit_style": fs.String("commit-style", "", "Commit style for this provider"),
+		}
+		setDefault := fs.Bool("default", false, "Make it the default provider")
+		if err := fs.Parse(args[2:]); err != nil {
+			return 1
+		}
+		change = func(raw map[string]any) error {
+			for _, field := range []string{"model", "api_key", "uri", "temperature", "commit_style"} {
+				if *values[field] == "" {
+					continue
+				}
+				if err := config.SetValue(raw, "providers."+name+"."+field, *values[field]); err != nil {
+					return err
+				}
+			}
+			if *setDefault {
+				raw["default_provider"] = name
+			}
+			cfg, err := config.Decode(raw)
+			if err != nil {
+				return err
+			}
+			if cfg.Providers[name].Model == "" {
+				return fmt.Errorf("-model is required for a new provider")
+			}
+			for _, p := range config.Validate(cfg) {
+				if strings.HasPrefix(p.Field, "providers."+name+".") {
+					return p
+				}
+			}
+			return nil
+		}
+	case "remove":
+		change = func(raw map[string]any) error {
+			providers, _ := raw["providers"].(map[string]any)
+			if _, ok := providers[name]; !ok {
+				return fmt.Errorf("provider %s is not configured", name)
+			}
+			if raw["default_provider"] == name && len(providers) > 1 {
+				return fmt.Errorf("%s is the default provider, run `gommit config provider set-default <name>` first", name)
+			}
+			delete(providers, name)
+			if len(providers) == 0 {
+				delete(raw, "providers")
+			}
+			if raw["default_provider"] == name {
+				delete(raw, "default_provider")
+			}
+			return nil
+		}
+	case "set-default":
+		change = func(raw map[string]any) error {
+			providers, _ := raw["providers"].(map[string]any)
+			if _, ok := providers[name]; !ok {
+				return fmt.Errorf("provider %s is not configured, run `gommit config provider add %s` first", name, name)
+			}
+			raw["default_provider"] = name
+			return nil
+		}
+	default:
+		colors.ErrorOutput("Error: invalid provider action %q\n%s", action, usage)
+		return 1
+	}
+
+	key := "providers." + name
+	if action == "set-default" {
+		key = "default_provider"
+	}
+	err := checkUnlocked(key)
+	if err == nil {
+		err = config.Edit(config.GetConfigPath(), change)
+	}
+	if err != nil {
+		colors.ErrorOutput("Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// checkUnlocked refuses changes to a key that the system or shared config locked; they would be
+// ignored anyway
+func checkUnlocked(key string) error {
+	if key == "locked" || strings.HasPrefix(key, "locked.") {
+		return fmt.Errorf("locked can only be set in the system or shared config")
+	}
+	eff, err := config.LoadEffective(false)
+	if errors.Is(err, config.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if owner, locked := eff.LockOwner(key); locked {
+		return fmt.Errorf("%s is locked by the %s config", key, owner)
+	}
+	return nil
+}
+
+// formatKeys lists the keys accepted by get/set/unset for the help
+func formatKeys() string {
+	var b strings.Builder
+	b.WriteString("Keys:\n")
+	line := " "
+	for _, key := range config.Keys() {
+		if len(line)+len(key)+1 > 100 {
+			b.WriteString(line + "\n")
+			line = " "
+		}
+		line += " " + key
+	}
+	b.WriteString(line + "\n")
+	return b.String()
+}
+
+// configShow implements `gommit config show [-effective]`: the user config file as written, or the
+// configuration merged from every layer with the origin of each value
+func configShow(args []string) int {
+	fs := configSubcommand("show", "show [flags]")
+	effective := fs.Bool("effective", false, "Show the merged configuration, where each value comes from and what is locked")
+	showSecrets := fs.Bool("show-secrets", false, "Show API keys instead of masking them")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if !*effective {
+		data, err := os.ReadFile(config.GetConfigPath())
+		if err != nil {
+			colors.ErrorOutput("Error: %v\n", err)
+			return 1
+		}
+		_, _ = os.Stdout.Write(data)
+		return 0
+	}
+
+	eff, err := config.LoadEffective(false)
+	if errors.Is(err, config.ErrNotFound) {
+		eff, err = &config.Effective{Config: &types.Config{}}, nil
+	}
+	if err != nil {
+		colors.ErrorOutput("Error loading configuration: %v\n", err)
+		return 1
+	}
+	for _, w := range eff.Warnings {
+		colors.WarningOutput("⚠️ %s\n", w)
+	}
+
+	colors.InfoOutput("Precedence (each layer overrides the ones above it, except for locked keys):\n")
+	found := map[string]config.Layer{}
+	for _, l := range eff.Layers {
+		found[l.Name] = l
+	}
+	for i, layer := range []struct{ name, path string }{
+		{config.LayerDefault, "built-in defaults"},
+		{config.LayerSystem, config.SystemConfigPath()},
+		{config.LayerShared, ""},
+		{config.LayerUser, config.GetConfigPath()},
+	} {
+		path, note := layer.path, ""
+		if l, ok := found[layer.name]; ok {
+			path = l.Path
+			if l.Cached != "" {
+				note = " (copy in " + l.Cached + ")"
+			}
+		} else if layer.name == config.LayerShared {
+			path, note = "shared.source", " (not set)"
+		} else if layer.name != config.LayerDefault {
+			note = " (not found)"
+		}
+		colors.TextOutput("  %d. %-10s %s%s\n", i+1, layer.name, path, note)
+	}
+	if repoRules, err := rules.Load(); err == nil && repoRules != nil && repoRules.Structured {
+		dropped := repoRules.DropLocked(eff.Config.IsLocked)
+		note := ""
+		if len(dropped) > 0 {
+			note = " (ignored because locked: " + strings.Join(dropped, ", ") + ")"
+		}
+		colors.TextOutput("  5. %-10s %s: history, commit types, scopes and ticket rules%s\n", "repository", repoRules.Path, note)
+	}
+
+	if len(eff.Config.Locked) > 0 {
+		colors.InfoOutput("\nLocked keys (the user and repository config cannot override them):\n")
+		for _, entry := range eff.Config.Locked {
+			colors.TextOutput("  %s (%s)\n", entry, eff.LockedBy[entry])
+		}
+	}
+
+	settings, origins, err := eff.Describe()
+	if err != nil {
+		colors.ErrorOutput("Error: %v\n", err)
+		return 1
+	}
+	colors.InfoOutput("\nEffective values:\n")
+	for _, s := range settings {
+		if s.Key == "locked" || s.Key == "version" {
+			continue
+		}
+		label := origins[s.Key]
+		if eff.Config.IsLocked(s.Key) {
+			label += ", locked"
+		}
+		colors.TextOutput("  %s=%s  [%s]\n", s.Key, displayValue(s, *showSecrets), label)
+	}
+
+	if len(eff.Ignored) > 0 {
+		colors.InfoOutput("\nIgnored because they are locked:\n")
+		for _, ig := range eff.Ignored {
+			colors.WarningOutput("  %s: %s=%s (locked by %s)\n", ig.Layer, ig.Key, displayValue(ig.Setting, *showSecrets), ig.LockedBy)
+		}
+	}
+	return 0
+}
+
+// displayValue formats a setting, masking API keys unless asked not to
+func displayValue(s config.Setting, showSecrets bool) string {
+	if strings.HasSuffix(s.Key, ".api_key") && !showSecrets {
+		return maskSecret(s.String())
+	}
+	return s.String()
+}
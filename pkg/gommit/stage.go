@@ -0,0 +1,65 @@
+package gommit
+
+import (
+	"fmt"
+
+	"github.com/manifoldco/promptui"
+
+	"github.com/edhuardotierrez/gommit/internal/git"
+)
+
+// interactiveStage lets the user pick among the unstaged and untracked files and stages the chosen ones.
+// It returns the number of files staged.
+func interactiveStage() (int, error) {
+	changes, err := git.GetUnstagedChanges()
+	if err != nil {
+		return 0, err
+	}
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	items := make([]string, len(changes))
+	for i, c := range changes {
+		items[i] = fmt.Sprintf("%s (%s)", c.Path, c.Status)
+	}
+
+	chosen, err := multiSelect("Select files to stage", items, make([]bool, len(items)))
+	if err != nil {
+		return 0, fmt.Errorf("file selection failed: %w", err)
+	}
+
+	var paths []string
+	for i, c := range changes {
+		if chosen[i] {
+			paths = append(paths, c.Path)
+		}
+	}
+	return len(paths), git.Stage(paths)
+}
+
+// multiSelect shows items with a checkbox each; selecting an item toggles it and "Done" returns the choice
+func multiSelect(label string, items []string, chosen []bool) ([]bool, error) {
+	cursor := 0
+	for {
+		options := []string{"✅ Done"}
+		for i, item := range items {
+			mark := "[ ]"
+			if chosen[i] {
+				mark = "[x]"
+			}
+			options = append(options, fmt.Sprintf("%s %s", mark, item))
+		}
+
+		s := promptui.Select{Label: label, Items: options, Size: min(len(options), 10), CursorPos: cursor}
+		idx, _, err := s.Run()
+		if err != nil {
+			return nil, err
+		}
+		if idx == 0 {
+			return chosen, nil
+		}
+		chosen[idx-1] = !chosen[idx-1]
+		cursor = idx
+	}
+}
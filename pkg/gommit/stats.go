@@ -0,0 +1,156 @@
+package gommit
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/edhuardotierrez/gommit/internal/colors"
+	"github.com/edhuardotierrez/gommit/internal/config"
+	"github.com/edhuardotierrez/gommit/internal/usage"
+)
+
+// statsCommand implements `gommit stats`: token usage, latency and estimated cost from the local usage log
+func statsCommand(fs *flag.FlagSet) func(args []string) int {
+	since := fs.String("since", "30d", "Period to summarize: a duration in days or weeks (30d, 2w) or a date (2006-01-02)")
+	by := fs.String("by", "", "Group by one of: "+strings.Join(usage.Dimensions, "|")+" (default: all)")
+	format := fs.String("format", "text", "Output format: text|json")
+
+	return func(args []string) int {
+		_ = fs.Parse(args)
+
+		if *format != "text" && *format != "json" {
+			colors.ErrorOutput("Error: invalid --format %q (expected: text|json)\n", *format)
+			return 1
+		}
+
+		from, err := parseSince(*since, time.Now())
+		if err != nil {
+			colors.ErrorOutput("Error: %v\n", err)
+			return 1
+		}
+
+		cfg, err := config.Read()
+		if err != nil {
+			colors.ErrorOutput("Error loading configuration: %v\n", err)
+			return 1
+		}
+
+		records, err := usage.Read(from)
+		if err != nil {
+			colors.ErrorOutput("Error: %v\n", err)
+			return 1
+		}
+		dimensions := usage.Dimensions
+		if *by != "" {
+			dimensions = []string{*by}
+		}
+
+		report := map[string][]usage.Summary{}
+		for _, d := range dimensions {
+			summaries, err := usage.Summarize(records, d, cfg.Usage.Prices)
+			if err != nil {
+				colors.ErrorOutput("Error: %v\n", err)
+				return 1
+			}
+			report[d] = summaries
+		}
+
+		if *format == "json" {
+			out, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(out))
+			return 0
+		}
+
+		if len(records) == 0 {
+			colors.InfoOutput("No usage recorded since %s\n", from.Format("2006-01-02"))
+			return 0
+		}
+
+		colors.InfoOutput("Usage since %s (%d requests)\n", from.Format("2006-01-02"), len(records))
+		for _, d := range dimensions {
+			colors.InfoOutput("\nBy %s:\n", d)
+			if d == "repo" {
+				shortenRepos(report[d])
+			}
+			printSummaries(report[d])
+		}
+
+		if cfg.Usage.MonthlyBudget > 0 {
+			spent, exceeded, err := usage.CheckBudget(cfg.Usage)
+			if err == nil {
+				line := fmt.Sprintf("\nMonthly budget: $%.2f of $%.2f spent\n", spent, cfg.Usage.MonthlyBudget)
+				if exceeded {
+					colors.WarningOutput(line)
+				} else {
+					colors.TextOutput(line)
+				}
+			}
+		}
+		return 0
+	}
+}
+
+// shortenRepos replaces the repository paths the summaries are grouped by with their base name, or
+// with as many trailing directories as it takes to tell apart checkouts with the same name
+func shortenRepos(summaries []usage.Summary) {
+	paths := make([][]string, len(summaries))
+	for i, s := range summaries {
+		if s.Key != "" {
+			paths[i] = strings.Split(filepath.ToSlash(filepath.Clean(s.Key)), "/")
+		}
+	}
+	suffix := func(parts []string, n int) string {
+		return strings.Join(parts[max(len(parts)-n, 0):], "/")
+	}
+
+	names := make([]string, len(summaries))
+	for i, parts := range paths {
+		if parts == nil {
+			continue
+		}
+		n := 1
+		for n < len(parts) && slices.ContainsFunc(paths, func(other []string) bool {
+			return other != nil && !slices.Equal(other, parts) && suffix(other, n) == suffix(parts, n)
+		}) {
+			n++
+		}
+		names[i] = filepath.FromSlash(suffix(parts, n))
+	}
+	for i := range summaries {
+		summaries[i].Key = names[i]
+	}
+}
+
+func printSummaries(summaries []usage.Summary) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  \tREQUESTS\tPROMPT TOKENS\tCOMPLETION TOKENS\tAVG LATENCY\tEST. COST")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "  %s\t%d\t%d\t%d\t%dms\t$%.4f\n", s.Key, s.Requests, s.PromptTokens, s.CompletionTokens, s.AvgLatencyMs, s.Cost)
+	}
+	_ = w.Flush()
+}
+
+// parseSince turns "30d", "2w" or "2006-01-02" into the start of the period
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return t, nil
+	}
+
+	days := 0
+	if n, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil && strings.HasSuffix(value, "d") {
+		days = n
+	} else if n, err := strconv.Atoi(strings.TrimSuffix(value, "w")); err == nil && strings.HasSuffix(value, "w") {
+		days = 7 * n
+	} else {
+		return time.Time{}, fmt.Errorf("invalid --since %q (expected e.g. 30d, 2w or 2006-01-02)", value)
+	}
+	return now.AddDate(0, 0, -days), nil
+}
@@ -0,0 +1,27 @@
+package gommit
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/edhuardotierrez/gommit/internal/usage"
+)
+
+// TestShortenRepos checks that repositories are shown by name, with parent directories only when
+// two checkouts share it.
+func TestShortenRepos(t *testing.T) {
+	summaries := []usage.Summary{
+		{Key: filepath.FromSlash("/src/work/api")},
+		{Key: filepath.FromSlash("/src/personal/api")},
+		{Key: filepath.FromSlash("/src/web")},
+		{Key: ""},
+	}
+	shortenRepos(summaries)
+
+	want := []string{filepath.FromSlash("work/api"), filepath.FromSlash("personal/api"), "web", ""}
+	for i, w := range want {
+		if summaries[i].Key != w {
+			t.Errorf("repo %d = %q, want %q", i, summaries[i].Key, w)
+		}
+	}
+}
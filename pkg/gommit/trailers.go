@@ -0,0 +1,123 @@
+package gommit
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/edhuardotierrez/gommit/internal/git"
+	"github.com/edhuardotierrez/gommit/internal/trailer"
+	"github.com/edhuardotierrez/gommit/internal/types"
+)
+
+// recentAuthorsLimit is how many commits are scanned for co-author suggestions
+const recentAuthorsLimit = 200
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// collectTrailers returns the configured default trailers followed by the co-authors, in a stable order
+func collectTrailers(cfg *types.Config, coAuthors []string, pick bool) ([]string, error) {
+	authors, err := resolveCoAuthors(coAuthors)
+	if err != nil {
+		return nil, err
+	}
+
+	if pick {
+		picked, err := pickCoAuthors(authors)
+		if err != nil {
+			return nil, err
+		}
+		authors = picked
+	}
+
+	trailers := append([]string{}, cfg.Trailers...)
+	for _, a := range authors {
+		trailers = append(trailers, trailer.CoAuthor(a))
+	}
+	return trailers, nil
+}
+
+// resolveCoAuthors turns --co-author values into "Name <email>"; values without an email are
+// matched (case-insensitively) against the recent commit authors
+func resolveCoAuthors(values []string) ([]string, error) {
+	var recent []string
+	var resolved []string
+
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if strings.Contains(v, "<") && strings.HasSuffix(v, ">") {
+			resolved = append(resolved, v)
+			continue
+		}
+
+		if recent == nil {
+			var err error
+			if recent, err = git.GetRecentAuthors(recentAuthorsLimit); err != nil {
+				return nil, err
+			}
+		}
+
+		var matches []string
+		for _, a := range recent {
+			if strings.Contains(strings.ToLower(a), strings.ToLower(v)) {
+				matches = append(matches, a)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("co-author %q not found among recent authors; use \"Name <email>\"", v)
+		case 1:
+			resolved = append(resolved, matches[0])
+		default:
+			return nil, fmt.Errorf("co-author %q is ambiguous: %s", v, strings.Join(matches, ", "))
+		}
+	}
+
+	return resolved, nil
+}
+
+// pickCoAuthors shows the recent commit authors and lets the user toggle co-authors on and off
+func pickCoAuthors(selected []string) ([]string, error) {
+	recent, err := git.GetRecentAuthors(recentAuthorsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := append([]string{}, selected...)
+	for _, a := range recent {
+		if !slices.Contains(candidates, a) {
+			candidates = append(candidates, a)
+		}
+	}
+	if len(candidates) == 0 {
+		return selected, nil
+	}
+
+	chosen := make([]bool, len(candidates))
+	for i, a := range candidates {
+		chosen[i] = slices.Contains(selected, a)
+	}
+
+	chosen, err = multiSelect("Select co-authors", candidates, chosen)
+	if err != nil {
+		return nil, fmt.Errorf("co-author selection failed: %w", err)
+	}
+
+	var out []string
+	for i, a := range candidates {
+		if chosen[i] {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}